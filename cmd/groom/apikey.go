@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"groom/internal/apikey"
+	"groom/internal/config"
+	"groom/internal/db"
+	"groom/internal/models"
+)
+
+const apikeyUsage = `Usage:
+  groom apikey create --name NAME --scopes SCOPES [--owner EMAIL] [--expires-in DURATION] [-- flags]
+  groom apikey list [-- flags]
+  groom apikey revoke ID [-- flags]
+
+Scopes: rooms:read, rooms:write, admin (comma separated).
+Configuration flags (--config, --database-url, ...) go after "--".`
+
+// groom apikey create|list|revoke
+func apikeyCommand(args []string) int {
+	if len(args) == 0 || args[0] == "help" {
+		fmt.Fprintln(os.Stderr, apikeyUsage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+
+	// Les options de la commande précèdent "--", les flags de configuration le suivent
+	var configArgs []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, configArgs = args[:i], args[i+1:]
+	}
+
+	cfg, err := config.Load("apikey", configArgs)
+	if err != nil {
+		printConfigErrors(err)
+		return 1
+	}
+	models.SetQueryTimeout(cfg.DatabaseQueryTimeout)
+
+	database, err := db.Connect(cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	switch command {
+	case "create":
+		return apikeyCreate(ctx, database, args)
+	case "list":
+		return apikeyList(ctx, database)
+	case "revoke":
+		return apikeyRevoke(ctx, database, args)
+	}
+	fmt.Fprintf(os.Stderr, "Unknown apikey command %q\n\n%s\n", command, apikeyUsage)
+	return 2
+}
+
+func apikeyCreate(ctx context.Context, database *sql.DB, args []string) int {
+	flagSet := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	name := flagSet.String("name", "", "Name of the key, e.g. the client application")
+	owner := flagSet.String("owner", "", "Email of the person responsible for the key")
+	scopes := flagSet.String("scopes", "", "Comma separated scopes: rooms:read, rooms:write, admin")
+	expiresIn := flagSet.Duration("expires-in", 0, "Lifetime of the key (0 never expires)")
+	if err := flagSet.Parse(args); err != nil {
+		return 2
+	}
+
+	var expiresAt *time.Time
+	if *expiresIn > 0 {
+		t := time.Now().Add(*expiresIn)
+		expiresAt = &t
+	}
+
+	secret, key, err := apikey.Issue(ctx, database, *name, *owner, splitScopes(*scopes), expiresAt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to create API key: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "API key %d %q created with scopes %s. Store it now, it will not be shown again:\n",
+		key.ID, key.Name, strings.Join(key.Scopes, ","))
+	fmt.Println(secret)
+	return 0
+}
+
+func apikeyList(ctx context.Context, database *sql.DB) int {
+	keys, err := models.GetAllAPIKeys(ctx, database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to list API keys: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tOWNER\tPREFIX\tSCOPES\tCREATED\tEXPIRES\tLAST USED\tSTATUS")
+	for _, key := range keys {
+		status := "active"
+		switch {
+		case key.RevokedAt != nil:
+			status = "revoked"
+		case key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()):
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\tgroom_%s\t%s\t%s\t%s\t%s\t%s\n",
+			key.ID, key.Name, key.Owner, key.Prefix, strings.Join(key.Scopes, ","),
+			formatTime(&key.CreatedAt), formatTime(key.ExpiresAt), formatTime(key.LastUsedAt), status)
+	}
+	w.Flush()
+	return 0
+}
+
+func apikeyRevoke(ctx context.Context, database *sql.DB, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, apikeyUsage)
+		return 2
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid API key ID %q\n", args[0])
+		return 2
+	}
+
+	revoked, err := models.RevokeAPIKey(ctx, database, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to revoke API key: %v\n", err)
+		return 1
+	}
+	if !revoked {
+		fmt.Fprintf(os.Stderr, "API key %d not found or already revoked\n", id)
+		return 1
+	}
+	fmt.Printf("API key %d revoked\n", id)
+	return 0
+}
+
+func splitScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
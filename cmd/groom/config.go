@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"groom/internal/config"
+)
+
+// groom config check [flags]
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "Usage: groom config check [flags]")
+		return 2
+	}
+
+	cfg, err := config.Load("config check", args[1:])
+	if err != nil {
+		printConfigErrors(err)
+		return 1
+	}
+
+	fmt.Printf("Configuration OK (web UI: %t, API: %t)\n", cfg.WebUIEnabled, cfg.APIEnabled)
+	return 0
+}
+
+func printConfigErrors(err error) {
+	var errs config.Errors
+	if !errors.As(err, &errs) {
+		errs = config.Errors{err}
+	}
+
+	fmt.Fprintf(os.Stderr, "Invalid configuration (%d problem(s)):\n", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(os.Stderr, "  - %s\n", e)
+	}
+}
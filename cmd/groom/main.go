@@ -1,67 +1,378 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"groom/internal/apikey"
 	"groom/internal/config"
 	"groom/internal/db"
 	googleapi "groom/internal/google"
+	"groom/internal/guestlink"
 	"groom/internal/handlers"
-	"log"
+	"groom/internal/health"
+	"groom/internal/jwtauth"
+	"groom/internal/lifecycle"
+	"groom/internal/logging"
+	"groom/internal/models"
+	"groom/internal/mtls"
+	"groom/internal/notify"
+	"groom/internal/ratelimit"
+	"groom/internal/rbac"
+	"groom/internal/session"
+	"groom/internal/telemetry"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		os.Exit(serve(args))
+	case "config":
+		os.Exit(configCommand(args))
+	case "apikey":
+		os.Exit(apikeyCommand(args))
+	case "jwt":
+		os.Exit(jwtCommand(args))
+	case "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", command)
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  groom [serve] [flags]      Start the server
+  groom config check [flags] Validate the configuration and report every problem
+  groom apikey create|list|revoke [options] [-- flags]
+                             Manage API keys (see "groom apikey help")
+  groom jwt keygen|sign [options]
+                             Generate a local key set and sign test tokens (see "groom jwt help")
+
+Flags:`)
+	config.Usage(os.Stderr, "groom")
+}
+
+func serve(args []string) int {
 	// Charger la configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.Load("serve", args)
+	if err != nil {
+		printConfigErrors(err)
+		return 1
+	}
+	cfgStore := config.NewStore(cfg)
+
+	// Logs structurés au format JSON
+	logging.Setup(cfg.LogLevel)
+
+	// Le gestionnaire de cycle de vie arrête les composants dans l'ordre inverse de leur démarrage
+	manager := lifecycle.NewManager()
+
+	// Initialisation du tracing OpenTelemetry (OTLP ou stdout)
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg)
+	if err != nil {
+		slog.Error("Could not set up tracing", slog.Any("error", err))
+		return 1
+	}
+	manager.Add(lifecycle.Component{Name: "tracing", Stop: shutdownTracing})
 
 	// Initialisation de la base de donées et exécution automatique des migrations
-	db.InitDatabase(cfg.DatabaseURL)
+	db.InitDatabase(cfg)
+	models.SetQueryTimeout(cfg.DatabaseQueryTimeout)
+	manager.Add(lifecycle.Component{Name: "database", Stop: func(context.Context) error { return db.Database.Close() }})
 	databaseName := "postgres"
 	if err := db.RunMigrations(cfg.DatabaseMigrationPath, databaseName); err != nil {
-		log.Fatalf("Could not run migrations: %v\n", err)
+		slog.Error("Could not run migrations", slog.Any("error", err))
+		return 1
 	}
-	defer db.Database.Close()
 
 	// Initialisation des composants Google (OAuth utilisateur ou compte de services, clients d'APIs, etc.)
-	googleapi.InitUserOAuth(cfg)
-	googleapi.InitServiceAccountServices(cfg)
+	if cfg.WebUIEnabled {
+		googleapi.InitUserOAuth(cfg)
+	}
+	if err := googleapi.InitServiceAccountServices(cfg); err != nil {
+		slog.Error("Could not initialize Google Meet client", slog.Any("error", err))
+		return 1
+	}
+
+	// Rôles des utilisateurs, avec résolution facultative des groupes Google
+	var groups rbac.GroupResolver
+	if cfg.RBACGroupsEnabled {
+		if err := googleapi.InitGroupService(cfg); err != nil {
+			slog.Error("Could not initialize the Directory API client", slog.Any("error", err))
+			return 1
+		}
+		groups = googleapi.GroupService
+	}
+	resolver := rbac.NewResolver(db.Database, cfgStore, groups)
+
+	// Liens invités signés, désactivés sans clé
+	guestLinks, err := guestlink.NewSigner(cfg)
+	if err != nil {
+		slog.Error("Could not configure guest links", slog.Any("error", err))
+		return 1
+	}
+
+	// Notifications des demandes de salles, désactivées sans webhook ni serveur SMTP
+	notifier, err := notify.New(cfg)
+	if err != nil {
+		slog.Error("Could not configure notifications", slog.Any("error", err))
+		return 1
+	}
+
+	// Limitation de débit, par instance ou partagée entre instances via Postgres
+	var limiter ratelimit.Limiter = ratelimit.NewMemoryLimiter()
+	if cfg.RateLimitBackend == "postgres" {
+		limiter = ratelimit.NewPostgresLimiter(db.Database)
+	}
+	manager.Add(lifecycle.Worker("ratelimit-cleanup", ratelimit.Cleanup(limiter, time.Minute)))
+	limitByIP := handlers.RateLimit(limiter, cfgStore, handlers.LimitByIP)
+	limitByUser := handlers.RateLimit(limiter, cfgStore, handlers.LimitByUser)
+
+	// En-têtes de sécurité (CSP avec nonce, HSTS, etc.) des pages HTML
+	secureHTML := handlers.SecurityHeaders(cfgStore)
+	// Vérifications des dépendances en tâche de fond, chacune avec son cache et son timeout
+	checks := []health.Check{
+		health.DatabaseCheck(db.Database, cfg.HealthCheckInterval, cfg.HealthCheckTimeout),
+		health.MigrationsCheck(db.Database, cfg.DatabaseMigrationPath, cfg.HealthCheckInterval, cfg.HealthCheckTimeout),
+		health.MeetCheck(googleapi.MeetService, cfg.MeetHealthCheckInterval, cfg.HealthCheckTimeout),
+	}
+	if cfg.WebUIEnabled {
+		checks = append(checks, health.OAuthConfigCheck(cfg.HealthCheckInterval))
+	}
+	checker := health.NewChecker(checks...)
+	manager.Add(lifecycle.Worker("health-checker", checker.Run))
+
+	// Rechargement à chaud de la configuration et des credentials (SIGHUP ou modification des fichiers)
+	manager.Add(lifecycle.Worker("config-reloader", newReloader("serve", args, cfgStore).run))
 
 	// Création du routeur Gin
-	r := gin.Default()
+	r := gin.New()
+	// Seuls les load balancers configurés peuvent fournir l'adresse du client (X-Forwarded-For, X-Real-IP)
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		slog.Error("Invalid trusted proxies", slog.Any("error", err))
+		return 1
+	}
+	r.Use(gin.Recovery())
+	r.Use(otelgin.Middleware(cfg.ServiceName))
+	r.Use(handlers.RequestIDMiddleware())
+	r.Use(handlers.RequestLogger())
 
 	// Configuration de la session
-	store := cookie.NewStore([]byte("secret"))
-	r.Use(sessions.Sessions("mysession", store))
+	if cfg.WebUIEnabled {
+		store, err := session.NewPostgresStore(db.Database, cfg)
+		if err != nil {
+			slog.Error("Could not configure sessions", slog.Any("error", err))
+			return 1
+		}
+		r.Use(session.ClientIPMiddleware())
+		r.Use(sessions.Sessions(cfg.SessionCookieName, store))
+		manager.Add(lifecycle.Worker("session-cleanup", store.Cleanup(cfg.SessionCleanupInterval)))
+	}
 
 	// Chargement des templates HTML
 	r.LoadHTMLGlob("templates/*")
 
 	// Routes pour l'authentification Google
-	r.GET("/auth/login", handlers.LoginHandler)
-	r.GET("/auth/callback", handlers.AuthCallbackHandler(cfg.GoogleWorkspaceDomain))
-	r.GET("/auth/logout", handlers.LogoutHandler)
+	if cfg.WebUIEnabled {
+		auth := r.Group("/auth", secureHTML)
+		{
+			auth.GET("/login", limitByIP, handlers.LoginHandler(cfgStore))
+			auth.GET("/callback", limitByIP, handlers.AuthCallbackHandler(cfgStore))
+			auth.GET("/logout", handlers.LogoutHandler(session.Options(cfg)))
+			auth.POST("/logout-everywhere", limitByIP, handlers.RequireLogin(), handlers.RequireCSRF(), handlers.LogoutEverywhereHandler(db.Database, session.Options(cfg)))
+		}
+
+		// Administration des salles et des sessions
+		admin := r.Group("/admin", secureHTML, handlers.RequireLogin(), limitByUser, handlers.LoadRole(resolver), handlers.RequireRole(rbac.Admin))
+		{
+			admin.GET("/sessions", handlers.ListSessionsHTMLHandler(db.Database))
+			admin.POST("/sessions/:id/revoke", handlers.RequireCSRF(), handlers.RevokeSessionFormHandler(db.Database))
+			admin.POST("/sessions/revoke-user", handlers.RequireCSRF(), handlers.RevokeUserSessionsFormHandler(db.Database))
+
+			admin.GET("/rooms", handlers.ListRoomsAdminHTMLHandler(db.Database))
+			admin.GET("/rooms/new", handlers.NewRoomFormHandler(cfgStore))
+			admin.POST("/rooms", handlers.RequireCSRF(), handlers.CreateRoomFormHandler(db.Database, googleapi.MeetService))
+			admin.GET("/rooms/:id/edit", handlers.EditRoomFormHandler(db.Database))
+			admin.POST("/rooms/:id", handlers.RequireCSRF(), handlers.UpdateRoomFormHandler(db.Database))
+			admin.POST("/rooms/:id/rotate-space", handlers.RequireCSRF(), handlers.RotateRoomSpaceFormHandler(db.Database, googleapi.MeetService))
+			admin.POST("/rooms/:id/archive", handlers.RequireCSRF(), handlers.ArchiveRoomFormHandler(db.Database, true))
+			admin.POST("/rooms/:id/unarchive", handlers.RequireCSRF(), handlers.ArchiveRoomFormHandler(db.Database, false))
+			admin.GET("/rooms/:id/history", handlers.RoomHistoryHTMLHandler(db.Database))
+			admin.POST("/rooms/:id/revert", handlers.RequireCSRF(), handlers.RevertRoomFormHandler(db.Database))
+
+			admin.GET("/room-requests", handlers.ListRoomRequestsHTMLHandler(db.Database))
+			admin.POST("/room-requests/:id/approve", handlers.RequireCSRF(), handlers.ApproveRoomRequestFormHandler(db.Database, googleapi.MeetService, notifier, cfgStore))
+			admin.POST("/room-requests/:id/reject", handlers.RequireCSRF(), handlers.RejectRoomRequestFormHandler(db.Database, notifier, cfgStore))
+
+			admin.GET("/personal-rooms", handlers.ListPersonalRoomsHTMLHandler(db.Database))
+			admin.POST("/personal-rooms/cleanup", handlers.RequireCSRF(), handlers.CleanupPersonalRoomsFormHandler(db.Database))
+			admin.POST("/personal-rooms/:id", handlers.RequireCSRF(), handlers.ReassignPersonalRoomFormHandler(db.Database))
+			admin.POST("/personal-rooms/:id/delete", handlers.RequireCSRF(), handlers.DeletePersonalRoomFormHandler(db.Database))
+		}
+	}
+
+	// Protected routes (by "X-API-KEY" HTTP header, JWT bearer token or client certificate), chaque groupe exigeant son scope
+	if cfg.APIEnabled {
+		if cfg.APIKey != "" {
+			slog.Warn("The shared api_key grants every scope; prefer keys issued with \"groom apikey create\"")
+		}
+
+		// Tokens JWT des services, vérifiés avec le JWKS de leur fournisseur OIDC
+		verifier, err := jwtauth.NewVerifier(context.Background(), cfg)
+		if err != nil {
+			slog.Error("Could not load the JWKS", slog.Any("error", err))
+			return 1
+		}
+		if verifier != nil {
+			manager.Add(lifecycle.Worker("jwks-refresher", verifier.Keys.Run))
+		}
+
+		// Certificats clients des services internes, vérifiés par le serveur TLS
+		clientCerts, err := mtls.ParseMapping(cfg.TLSClientIdentityMapping)
+		if err != nil {
+			slog.Error("Invalid client certificate mapping", slog.Any("error", err))
+			return 1
+		}
+		if len(clientCerts) == 0 {
+			clientCerts = nil
+		}
+
+		// La limite par IP s'applique avant l'authentification pour freiner la recherche de clés
+		api := r.Group("/api", limitByIP, handlers.APIAuthMiddleware(db.Database, cfgStore, verifier, clientCerts), handlers.RateLimit(limiter, cfgStore, handlers.LimitByAPIClient))
+
+		roomsRead := api.Group("", handlers.RequireScope(apikey.ScopeRoomsRead))
+		{
+			roomsRead.GET("/rooms", handlers.ListRoomsJSONHandler(db.Database))
+			roomsRead.GET("/rooms/:id/access", handlers.GetRoomAccessHandler(db.Database))
+			roomsRead.GET("/rooms/:id/history", handlers.RoomHistoryJSONHandler(db.Database))
+		}
+
+		roomsWrite := api.Group("", handlers.RequireScope(apikey.ScopeRoomsWrite))
+		{
+			roomsWrite.POST("/rooms", handlers.CreateRoomHandler(db.Database, googleapi.MeetService, cfgStore))
+			roomsWrite.PUT("/rooms/:id", handlers.UpdateRoomHandler(db.Database))
+			roomsWrite.DELETE("/rooms/:id", handlers.DeleteRoomHandler(db.Database))
+			roomsWrite.PUT("/rooms/:id/access", handlers.UpdateRoomAccessHandler(db.Database))
+			roomsWrite.POST("/rooms/:id/rotate-space", handlers.RotateRoomSpaceHandler(db.Database, googleapi.MeetService))
+			roomsWrite.POST("/rooms/:id/archive", handlers.ArchiveRoomHandler(db.Database, true))
+			roomsWrite.POST("/rooms/:id/unarchive", handlers.ArchiveRoomHandler(db.Database, false))
+			roomsWrite.POST("/rooms/:id/revert", handlers.RevertRoomHandler(db.Database))
+			if guestLinks != nil {
+				roomsWrite.GET("/rooms/:id/guest-links", handlers.ListGuestLinksHandler(db.Database))
+				roomsWrite.POST("/rooms/:id/guest-links", handlers.CreateGuestLinkHandler(db.Database, guestLinks, cfgStore))
+				roomsWrite.DELETE("/guest-links/:id", handlers.RevokeGuestLinkHandler(db.Database))
+			}
+		}
+
+		apiAdmin := api.Group("", handlers.RequireScope(apikey.ScopeAdmin))
+		{
+			apiAdmin.GET("/sessions", handlers.ListSessionsJSONHandler(db.Database))
+			apiAdmin.DELETE("/sessions/:id", handlers.RevokeSessionHandler(db.Database))
+			apiAdmin.DELETE("/users/:email/sessions", handlers.RevokeUserSessionsHandler(db.Database))
+
+			apiAdmin.GET("/keys", handlers.ListAPIKeysHandler(db.Database))
+			apiAdmin.POST("/keys", handlers.CreateAPIKeyHandler(db.Database))
+			apiAdmin.DELETE("/keys/:id", handlers.RevokeAPIKeyHandler(db.Database))
 
-	// Protected routes (by "X-API-TOKEN" HTTP header)
-	api := r.Group("/api", handlers.ApiKeyMiddleware(cfg.APIKey))
-	{
-		api.GET("/rooms", handlers.ListRoomsJSONHandler(db.Database))
-		api.POST("/rooms", handlers.CreateRoomHandler(db.Database, googleapi.MeetService))
-		api.PUT("/rooms/:id", handlers.UpdateRoomHandler(db.Database))
-		api.DELETE("/rooms/:id", handlers.DeleteRoomHandler(db.Database))
+			apiAdmin.GET("/role-bindings", handlers.ListRoleBindingsHandler(db.Database))
+			apiAdmin.POST("/role-bindings", handlers.SaveRoleBindingHandler(db.Database, resolver))
+			apiAdmin.DELETE("/role-bindings/:id", handlers.DeleteRoleBindingHandler(db.Database, resolver))
+
+			apiAdmin.GET("/audit", handlers.ListAuditEventsHandler(db.Database))
+
+			apiAdmin.GET("/room-requests", handlers.ListRoomRequestsHandler(db.Database))
+			apiAdmin.POST("/room-requests/:id/approve", handlers.ApproveRoomRequestHandler(db.Database, googleapi.MeetService, notifier, cfgStore))
+			apiAdmin.POST("/room-requests/:id/reject", handlers.RejectRoomRequestHandler(db.Database, notifier, cfgStore))
+
+			apiAdmin.GET("/personal-rooms", handlers.ListPersonalRoomsHandler(db.Database))
+			apiAdmin.POST("/personal-rooms/cleanup", handlers.CleanupPersonalRoomsHandler(db.Database))
+			apiAdmin.PUT("/personal-rooms/:id", handlers.ReassignPersonalRoomHandler(db.Database))
+			apiAdmin.DELETE("/personal-rooms/:id", handlers.DeletePersonalRoomHandler(db.Database))
+		}
 	}
 
 	// System routes
-	r.GET("/healthz", handlers.HealthzHandler(db.Database, googleapi.MeetService))
+	r.GET("/livez", handlers.LivezHandler)
+	r.GET("/readyz", handlers.ReadyzHandler(checker))
+	r.GET("/healthz", handlers.HealthzHandler(checker))
 
 	// Open routes
-	r.GET("/", handlers.RequireLogin(), handlers.ListRoomsHTMLHandler(db.Database, googleapi.MeetService))
-	r.GET("/:slug", handlers.RedirectHandler(db.Database, googleapi.MeetService))
+	if cfg.WebUIEnabled {
+		r.GET("/", secureHTML, handlers.RequireLogin(), limitByUser, handlers.LoadRole(resolver), handlers.RequireRole(rbac.Viewer), handlers.ListRoomsHTMLHandler(db.Database, googleapi.MeetService, resolver, cfgStore))
+
+		// Création de salles en libre-service, si room_self_service_enabled
+		selfService := r.Group("/rooms", secureHTML, handlers.RequireLogin(), limitByUser, handlers.LoadRole(resolver), handlers.RequireRole(rbac.Viewer))
+		{
+			selfService.GET("/new", handlers.NewSelfServiceRoomHandler(cfgStore, resolver))
+			selfService.POST("", handlers.RequireCSRF(), handlers.CreateSelfServiceRoomHandler(db.Database, googleapi.MeetService, notifier, cfgStore, resolver))
+			selfService.GET("/requests", handlers.ListMyRoomRequestsHandler(db.Database))
+		}
+
+		// Salle personnelle de l'utilisateur connecté, si personal_rooms_enabled
+		r.GET("/me", secureHTML, handlers.RequireLogin(), limitByUser, handlers.LoadRole(resolver), handlers.RequireRole(rbac.Viewer), handlers.MyRoomHandler(db.Database, googleapi.MeetService, cfgStore))
+	}
+	// Lien public des salles personnelles
+	r.GET("/u/:slug", secureHTML, limitByIP, handlers.PersonalRoomRedirectHandler(db.Database, googleapi.MeetService, cfgStore))
+	// Les rooms non publiques vérifient elles-mêmes la connexion et les autorisations
+	r.GET("/:slug", secureHTML, limitByIP, handlers.RedirectHandler(db.Database, googleapi.MeetService, resolver, guestLinks))
+
+	// Démarrer le serveur et les tâches de fond
+	serverFailed := make(chan error, 1)
+	// HTTPS servi directement si un certificat est configuré, rechargé quand ses fichiers changent
+	certs, err := mtls.Load(cfg)
+	if err != nil {
+		slog.Error("Could not load TLS certificates", slog.Any("error", err))
+		return 1
+	}
+	if certs != nil {
+		manager.Add(lifecycle.Worker("tls-reloader", certs.Watch(cfg.TLSReloadInterval)))
+	}
+	manager.Add(httpServerComponent(newHTTPServer(cfg, r, certs), serverFailed))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.Start(ctx); err != nil {
+		slog.Error("Server failed to start", slog.Any("error", err))
+		return 1
+	}
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received", slog.Duration("drain_period", cfg.ShutdownDrainPeriod))
+		// Laisser au load balancer le temps de constater que /readyz échoue avant de fermer le port
+		checker.Drain()
+		time.Sleep(cfg.ShutdownDrainPeriod)
+	case err := <-serverFailed:
+		slog.Error("Server failed", slog.Any("error", err))
+		exitCode = 1
+	}
 
-	// Démarrer le serveur
-	log.Printf("Server started at %s:%s", cfg.Host, cfg.Port)
-	if err := r.Run(cfg.Host + ":" + cfg.Port); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := manager.Stop(shutdownCtx); err != nil {
+		slog.Error("Graceful shutdown failed", slog.Any("error", err))
+		exitCode = 1
 	}
+	slog.Info("Server stopped")
+	return exitCode
 }
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+	"groom/internal/logging"
+	"groom/internal/models"
+)
+
+// reloader recharge la configuration et les credentials du compte de service sur SIGHUP,
+// ou quand le fichier de configuration ou de credentials change sur le disque.
+type reloader struct {
+	name   string
+	args   []string
+	store  *config.Store
+	stamps map[string]fileStamp
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newReloader(name string, args []string, store *config.Store) *reloader {
+	r := &reloader{name: name, args: args, store: store}
+	r.stamps = r.snapshot()
+	return r
+}
+
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval := r.store.Get().ConfigWatchInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload(ctx, "signal")
+		case <-tick:
+			if stamps := r.snapshot(); !sameStamps(stamps, r.stamps) {
+				r.reload(ctx, "file change")
+			}
+		}
+	}
+}
+
+// snapshot relève la date de modification et la taille des fichiers surveillés.
+func (r *reloader) snapshot() map[string]fileStamp {
+	cfg := r.store.Get()
+	stamps := make(map[string]fileStamp)
+	for _, path := range []string{cfg.File, cfg.GoogleServiceAccountCredentialsFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if b[path] != stamp {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *reloader) reload(ctx context.Context, trigger string) {
+	// Les fichiers sont marqués comme vus même en cas d'échec, pour ne pas réessayer en boucle
+	defer func() { r.stamps = r.snapshot() }()
+
+	next, err := config.Load(r.name, r.args)
+	if err != nil {
+		slog.ErrorContext(ctx, "Configuration reload failed, keeping the current configuration", slog.String("trigger", trigger), slog.Any("error", err))
+		return
+	}
+
+	merged, changes := config.Merge(r.store.Get(), next)
+
+	// Le client Meet est reconstruit à chaque rechargement : le contenu du fichier de credentials a pu changer
+	if err := googleapi.MeetService.Reload(merged); err != nil {
+		slog.ErrorContext(ctx, "Configuration reload failed, keeping the current configuration", slog.String("trigger", trigger), slog.Any("error", err))
+		return
+	}
+	if googleapi.GroupService != nil {
+		if err := googleapi.GroupService.Reload(merged); err != nil {
+			slog.ErrorContext(ctx, "Configuration reload failed, keeping the current configuration", slog.String("trigger", trigger), slog.Any("error", err))
+			return
+		}
+	}
+	if merged.WebUIEnabled {
+		googleapi.InitUserOAuth(merged)
+	}
+	logging.SetLevel(merged.LogLevel)
+	models.SetQueryTimeout(merged.DatabaseQueryTimeout)
+	r.store.Set(merged)
+
+	slog.InfoContext(ctx, "Configuration reloaded",
+		slog.String("trigger", trigger),
+		slog.Any("changed", changes.Applied),
+	)
+	if len(changes.RestartRequired) > 0 {
+		slog.WarnContext(ctx, "Some configuration changes require a restart", slog.Any("keys", changes.RestartRequired))
+	}
+}
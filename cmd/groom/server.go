@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"groom/internal/config"
+	"groom/internal/lifecycle"
+	"groom/internal/mtls"
+)
+
+// Avec des certificats (certs non nil), le serveur sert directement en HTTPS.
+func newHTTPServer(cfg config.Config, handler http.Handler, certs *mtls.Certificates) *http.Server {
+	server := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, cfg.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
+	}
+	if certs != nil {
+		server.TLSConfig = certs.TLSConfig()
+	}
+	return server
+}
+
+// httpServerComponent ouvre le port au démarrage (pour remonter immédiatement une erreur d'écoute)
+// puis sert en tâche de fond. Une erreur de service est transmise sur failed.
+func httpServerComponent(server *http.Server, failed chan<- error) lifecycle.Component {
+	return lifecycle.Component{
+		Name: "http-server",
+		Start: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			slog.InfoContext(ctx, "Server started", slog.String("addr", server.Addr), slog.Bool("tls", server.TLSConfig != nil))
+
+			go func() {
+				serve := server.Serve
+				if server.TLSConfig != nil {
+					// Les certificats sont fournis par TLSConfig et rechargés à chaud
+					serve = func(l net.Listener) error { return server.ServeTLS(l, "", "") }
+				}
+				if err := serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					failed <- err
+				}
+			}()
+			return nil
+		},
+		// Shutdown attend la fin des requêtes en cours, dans la limite du contexte d'arrêt
+		Stop: server.Shutdown,
+	}
+}
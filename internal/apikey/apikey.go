@@ -0,0 +1,116 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"groom/internal/models"
+)
+
+// Scopes accordés aux clés d'API ; "admin" les inclut tous.
+const (
+	ScopeRoomsRead  = "rooms:read"
+	ScopeRoomsWrite = "rooms:write"
+	ScopeAdmin      = "admin"
+)
+
+var Scopes = []string{ScopeRoomsRead, ScopeRoomsWrite, ScopeAdmin}
+
+// Format d'une clé : groom_<préfixe de 12 caractères hexadécimaux>_<secret>
+const (
+	keyPrefix    = "groom_"
+	prefixLength = 12
+)
+
+// HasScope indique si les scopes accordés couvrent le scope demandé.
+func HasScope(granted []string, scope string) bool {
+	return slices.Contains(granted, scope) || slices.Contains(granted, ScopeAdmin)
+}
+
+// ValidateScopes vérifie qu'au moins un scope est demandé et que tous sont connus.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(Scopes, ", "))
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return fmt.Errorf("unknown scope %q (expected %s)", scope, strings.Join(Scopes, ", "))
+		}
+	}
+	return nil
+}
+
+// Issue crée une clé et renvoie son secret complet, qui n'est plus jamais affiché ensuite.
+func Issue(ctx context.Context, db *sql.DB, name, owner string, scopes []string, expiresAt *time.Time) (string, *models.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", nil, fmt.Errorf("name is required")
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return "", nil, err
+	}
+
+	prefix := make([]byte, prefixLength/2)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	key := &models.APIKey{
+		Name:      name,
+		Owner:     owner,
+		Prefix:    hex.EncodeToString(prefix),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key.SecretHash = hashSecret(encodedSecret)
+
+	if err := models.CreateAPIKey(ctx, db, key); err != nil {
+		return "", nil, err
+	}
+	return keyPrefix + key.Prefix + "_" + encodedSecret, key, nil
+}
+
+// Authenticate renvoie la clé correspondant au secret présenté, ou nil si elle est inconnue,
+// révoquée, expirée ou si le secret ne correspond pas.
+func Authenticate(ctx context.Context, db *sql.DB, presented string) (*models.APIKey, error) {
+	rest, ok := strings.CutPrefix(presented, keyPrefix)
+	if !ok || len(rest) <= prefixLength+1 || rest[prefixLength] != '_' {
+		return nil, nil
+	}
+	prefix, secret := rest[:prefixLength], rest[prefixLength+1:]
+
+	key, err := models.GetAPIKeyByPrefix(ctx, db, prefix)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, nil
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now())) {
+		return nil, nil
+	}
+
+	if err := models.TouchAPIKey(ctx, db, key.ID); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Le secret contient 256 bits aléatoires : un SHA-256 suffit, sans sel ni dérivation lente.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,82 +1,123 @@
 package config
 
 import (
-	"log"
-	"os"
+	"time"
 )
 
+// Chaque champ est décrit par ses tags :
+//   - key : clé dans le fichier de configuration (YAML ou TOML) ; le flag CLI correspondant est --key-avec-tirets
+//   - env : variable d'environnement
+//   - default : valeur par défaut
+//   - secret : la valeur n'est jamais affichée
+//   - reload : la valeur peut changer sans redémarrage (SIGHUP ou modification du fichier)
+//
+// Ordre de priorité : valeurs par défaut < fichier < variables d'environnement < flags.
 type Config struct {
-	Host                                 string
-	Port                                 string
-	DatabaseURL                          string
-	DatabaseMigrationPath                string
-	Username                             string
-	Password                             string
-	APIKey                               string
-	GoogleWorkspaceDomain                string
-	GoogleAPIKey                         string
-	GoogleClientID                       string
-	GoogleClientSecret                   string
-	GoogleRedirectURL                    string
-	GoogleServiceAccountCredentialsFile  string
-	GoogleServiceAccountImpersonatedUser string // email
-}
+	File string `key:"-"` // fichier de configuration effectivement lu, le cas échéant
 
-func LoadConfig() Config {
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatal("DATABASE_URL is not set")
-	}
-
-	googleWorkspaceDomain := os.Getenv("GOOGLE_WORKSPACE_DOMAIN")
-	if googleWorkspaceDomain == "" {
-		log.Fatal("GOOGLE_WORKSPACE_DOMAIN is not set")
-	}
-
-	googleAPIKey := os.Getenv("GOOGLE_API_KEY")
-	if googleAPIKey == "" {
-		log.Fatal("GOOGLE_API_KEY is not set")
-	}
-
-	googleClientID := os.Getenv("GOOGLE_CLIENT_ID")
-	if googleClientID == "" {
-		log.Fatal("GOOGLE_CLIENT_ID is not set")
-	}
-
-	googleClientSecret := os.Getenv("GOOGLE_CLIENT_SECRET")
-	if googleClientSecret == "" {
-		log.Fatal("GOOGLE_CLIENT_SECRET is not set")
-	}
-
-	googleRedirectURL := os.Getenv("GOOGLE_REDIRECT_URL")
-	if googleRedirectURL == "" {
-		log.Fatal("GOOGLE_REDIRECT_URL is not set")
-	}
-
-	googleServiceAccountImpersonatedUser := os.Getenv("GOOGLE_SERVICE_ACCOUNT_IMPERSONATED_USER")
-	if googleServiceAccountImpersonatedUser == "" {
-		log.Fatal("GOOGLE_SERVICE_ACCOUNT_IMPERSONATED_USER is not set")
-	}
-
-	return Config{
-		Host:                                 getEnv("HOST", "0.0.0.0"),
-		Port:                                 getEnv("PORT", "3000"),
-		DatabaseURL:                          os.Getenv("DATABASE_URL"),
-		DatabaseMigrationPath:                getEnv("DATABASE_MIGRATION_PATH", "./migrations"),
-		APIKey:                               os.Getenv("GROOM_API_KEY"),
-		GoogleWorkspaceDomain:                os.Getenv("GOOGLE_WORKSPACE_DOMAIN"),
-		GoogleAPIKey:                         os.Getenv("GOOGLE_API_KEY"),
-		GoogleClientID:                       os.Getenv("GOOGLE_CLIENT_ID"),
-		GoogleClientSecret:                   os.Getenv("GOOGLE_CLIENT_SECRET"),
-		GoogleRedirectURL:                    os.Getenv("GOOGLE_REDIRECT_URL"),
-		GoogleServiceAccountCredentialsFile:  getEnv("GOOGLE_SERVICE_ACCOUNT_CREDENTIALS_FILE", "./service_account.json"),
-		GoogleServiceAccountImpersonatedUser: os.Getenv("GOOGLE_SERVICE_ACCOUNT_IMPERSONATED_USER"),
-	}
-}
+	WebUIEnabled bool `key:"web_ui_enabled" env:"GROOM_WEB_UI_ENABLED" default:"true" usage:"Enable the browser UI and Google sign-in"`
+	APIEnabled   bool `key:"api_enabled" env:"GROOM_API_ENABLED" default:"true" usage:"Enable the /api routes"`
+
+	DemoMode bool `key:"demo_mode" env:"GROOM_DEMO_MODE" default:"false" usage:"Allow insecure defaults (built-in session key) for local demos"`
+
+	Host string `key:"host" env:"HOST" default:"0.0.0.0" usage:"Listen address"`
+	Port string `key:"port" env:"PORT" default:"3000" usage:"Listen port"`
+
+	TLSCertFile              string        `key:"tls_cert_file" env:"TLS_CERT_FILE" usage:"PEM certificate served over HTTPS; plain HTTP when empty"`
+	TLSKeyFile               string        `key:"tls_key_file" env:"TLS_KEY_FILE" secret:"true" usage:"PEM private key of tls_cert_file"`
+	TLSClientCAFile          string        `key:"tls_client_ca_file" env:"TLS_CLIENT_CA_FILE" usage:"PEM bundle of the CAs allowed to issue API client certificates"`
+	TLSClientIdentityMapping []string      `key:"tls_client_identity_mapping" env:"TLS_CLIENT_IDENTITY_MAPPING" usage:"type:value=scope entries (type is cn, dns, uri or email) mapping client certificates to API scopes"`
+	TLSReloadInterval        time.Duration `key:"tls_reload_interval" env:"TLS_RELOAD_INTERVAL" default:"1m" usage:"How often certificate files are checked for changes"`
+
+	TrustedProxies        []string      `key:"trusted_proxies" env:"TRUSTED_PROXIES" usage:"CIDRs or IPs of the load balancers allowed to set X-Forwarded-For; none are trusted when empty"`
+	HSTSMaxAge            time.Duration `key:"hsts_max_age" env:"HSTS_MAX_AGE" default:"4320h" reload:"true" usage:"Strict-Transport-Security max-age on HTML pages (0 disables)"`
+	HSTSIncludeSubdomains bool          `key:"hsts_include_subdomains" env:"HSTS_INCLUDE_SUBDOMAINS" default:"false" reload:"true"`
+
+	DatabaseURL             string        `key:"database_url" env:"DATABASE_URL" secret:"true" usage:"Postgres connection URL"`
+	DatabaseMigrationPath   string        `key:"database_migration_path" env:"DATABASE_MIGRATION_PATH" default:"./migrations" usage:"Directory containing SQL migrations"`
+	DatabaseMaxOpenConns    int           `key:"database_max_open_conns" env:"DATABASE_MAX_OPEN_CONNS" default:"10"`
+	DatabaseMaxIdleConns    int           `key:"database_max_idle_conns" env:"DATABASE_MAX_IDLE_CONNS" default:"5"`
+	DatabaseConnMaxLifetime time.Duration `key:"database_conn_max_lifetime" env:"DATABASE_CONN_MAX_LIFETIME" default:"30m"`
+	DatabaseConnMaxIdleTime time.Duration `key:"database_conn_max_idle_time" env:"DATABASE_CONN_MAX_IDLE_TIME" default:"5m"`
+	DatabaseQueryTimeout    time.Duration `key:"database_query_timeout" env:"DATABASE_QUERY_TIMEOUT" default:"5s" reload:"true"`
+	MeetCallTimeout         time.Duration `key:"meet_call_timeout" env:"MEET_CALL_TIMEOUT" default:"10s" reload:"true"`
+
+	SessionSigningKeys     []string      `key:"session_signing_keys" env:"SESSION_SIGNING_KEYS" secret:"true" usage:"Base64 session signing keys, newest first; older keys are only used to verify"`
+	SessionEncryptionKeys  []string      `key:"session_encryption_keys" env:"SESSION_ENCRYPTION_KEYS" secret:"true" usage:"Base64 AES keys (16, 24 or 32 bytes), one per signing key"`
+	SessionCookieName      string        `key:"session_cookie_name" env:"SESSION_COOKIE_NAME" default:"mysession"`
+	SessionCookieSecure    bool          `key:"session_cookie_secure" env:"SESSION_COOKIE_SECURE" default:"true"`
+	SessionCookieSameSite  string        `key:"session_cookie_same_site" env:"SESSION_COOKIE_SAME_SITE" default:"lax" usage:"lax, strict or none"`
+	SessionCookieDomain    string        `key:"session_cookie_domain" env:"SESSION_COOKIE_DOMAIN"`
+	SessionMaxAge          time.Duration `key:"session_max_age" env:"SESSION_MAX_AGE" default:"168h" usage:"Idle lifetime of a session, extended on each visit"`
+	SessionCleanupInterval time.Duration `key:"session_cleanup_interval" env:"SESSION_CLEANUP_INTERVAL" default:"1h"`
+
+	AdminEmails       []string `key:"admin_emails" env:"GROOM_ADMIN_EMAILS" reload:"true" usage:"Users always granted the admin role, e.g. to create the first role bindings"`
+	RBACDefaultRole   string   `key:"rbac_default_role" env:"RBAC_DEFAULT_ROLE" default:"viewer" reload:"true" usage:"Role of signed-in users without a binding: viewer, editor, admin or none"`
+	RBACGroupsEnabled bool     `key:"rbac_groups_enabled" env:"RBAC_GROUPS_ENABLED" default:"false" usage:"Resolve group role bindings with the Directory API (needs admin.directory.group.readonly delegation)"`
+
+	RoomDefaultAccessPolicy string `key:"room_default_access_policy" env:"ROOM_DEFAULT_ACCESS_POLICY" default:"public" reload:"true" usage:"Access policy of new rooms: public, domain or restricted"`
+	RoomSelfServiceEnabled  bool   `key:"room_self_service_enabled" env:"ROOM_SELF_SERVICE_ENABLED" default:"false" reload:"true" usage:"Let signed-in users of the Workspace domains create their own rooms from the room list"`
+	RoomMaxPerUser          int    `key:"room_max_per_user" env:"ROOM_MAX_PER_USER" default:"3" reload:"true" usage:"Rooms a user may own through self-service, archived rooms excluded (0: unlimited)"`
+	RoomMaxPerTeam          int    `key:"room_max_per_team" env:"ROOM_MAX_PER_TEAM" default:"10" reload:"true" usage:"Self-service rooms per team (Google group of the creator), archived rooms excluded (0: unlimited)"`
+	RoomApprovalRequired    bool   `key:"room_approval_required" env:"ROOM_APPROVAL_REQUIRED" default:"false" reload:"true" usage:"Queue self-service rooms as requests until an admin approves them"`
+	PersonalRoomsEnabled    bool   `key:"personal_rooms_enabled" env:"PERSONAL_ROOMS_ENABLED" default:"false" reload:"true" usage:"Give each signed-in user a permanent room at /me, public at /u/<slug>"`
+
+	NotifyWebhookURL    string `key:"notify_webhook_url" env:"NOTIFY_WEBHOOK_URL" usage:"URL receiving room request notifications as JSON POSTs"`
+	NotifyWebhookSecret string `key:"notify_webhook_secret" env:"NOTIFY_WEBHOOK_SECRET" secret:"true" usage:"Base64 HMAC key signing webhook bodies (X-Groom-Signature header)"`
+	SMTPAddr            string `key:"smtp_addr" env:"SMTP_ADDR" usage:"host:port of the SMTP server emailing requesters; email notifications are disabled when empty"`
+	SMTPUsername        string `key:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword        string `key:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom            string `key:"smtp_from" env:"SMTP_FROM" usage:"Sender address of notification emails"`
+
+	GuestLinkSigningKeys []string      `key:"guest_link_signing_keys" env:"GUEST_LINK_SIGNING_KEYS" secret:"true" usage:"Base64 HMAC keys for guest links, newest first; guest links are disabled when empty"`
+	GuestLinkMaxTTL      time.Duration `key:"guest_link_max_ttl" env:"GUEST_LINK_MAX_TTL" default:"720h" reload:"true" usage:"Longest validity accepted when minting a guest link"`
+	PublicURL            string        `key:"public_url" env:"GROOM_PUBLIC_URL" usage:"External base URL, used to build absolute guest links"`
+
+	RateLimitEnabled   bool          `key:"rate_limit_enabled" env:"RATE_LIMIT_ENABLED" default:"true" reload:"true"`
+	RateLimitBackend   string        `key:"rate_limit_backend" env:"RATE_LIMIT_BACKEND" default:"memory" usage:"memory (per instance) or postgres (shared between instances)"`
+	RateLimitWindow    time.Duration `key:"rate_limit_window" env:"RATE_LIMIT_WINDOW" default:"1m" reload:"true"`
+	RateLimitPerIP     int           `key:"rate_limit_per_ip" env:"RATE_LIMIT_PER_IP" default:"120" reload:"true" usage:"Requests per window and client IP on redirects, sign-in and /api (0 disables)"`
+	RateLimitPerAPIKey int           `key:"rate_limit_per_api_key" env:"RATE_LIMIT_PER_API_KEY" default:"600" reload:"true" usage:"Requests per window and API client (key or JWT subject, 0 disables)"`
+	RateLimitPerUser   int           `key:"rate_limit_per_user" env:"RATE_LIMIT_PER_USER" default:"300" reload:"true" usage:"Requests per window and signed-in user (0 disables)"`
+
+	APIKey string `key:"api_key" env:"GROOM_API_KEY" secret:"true" usage:"Optional shared API key accepted in X-API-KEY with every scope (deprecated)" reload:"true"`
+
+	SignInAllowedDomains       []string `key:"signin_allowed_domains" env:"SIGNIN_ALLOWED_DOMAINS" reload:"true" usage:"Other Google Workspace domains (hd claim) whose users may sign in"`
+	SignInAllowedEmails        []string `key:"signin_allowed_emails" env:"SIGNIN_ALLOWED_EMAILS" reload:"true" usage:"Accounts allowed to sign in whatever their domain"`
+	SignInDeniedEmails         []string `key:"signin_denied_emails" env:"SIGNIN_DENIED_EMAILS" reload:"true" usage:"Accounts refused even within an allowed domain"`
+	SignInRequireEmailVerified bool     `key:"signin_require_email_verified" env:"SIGNIN_REQUIRE_EMAIL_VERIFIED" default:"true" reload:"true"`
+
+	JWTJWKSURL             string        `key:"jwt_jwks_url" env:"JWT_JWKS_URL" usage:"JWKS URL used to verify bearer tokens on /api"`
+	JWTJWKSFile            string        `key:"jwt_jwks_file" env:"JWT_JWKS_FILE" usage:"Local JWKS file, instead of jwt_jwks_url"`
+	JWTJWKSRefreshInterval time.Duration `key:"jwt_jwks_refresh_interval" env:"JWT_JWKS_REFRESH_INTERVAL" default:"1h"`
+	JWTIssuers             []string      `key:"jwt_issuers" env:"JWT_ISSUERS" usage:"Accepted iss claims"`
+	JWTAudiences           []string      `key:"jwt_audiences" env:"JWT_AUDIENCES" usage:"Accepted aud claims (any match)"`
+	JWTScopeClaim          string        `key:"jwt_scope_claim" env:"JWT_SCOPE_CLAIM" default:"scope"`
+	JWTScopeMapping        []string      `key:"jwt_scope_mapping" env:"JWT_SCOPE_MAPPING" usage:"value=scope pairs translating scope claim values to groom scopes"`
+
+	GoogleWorkspaceDomain                string `key:"google_workspace_domain" env:"GOOGLE_WORKSPACE_DOMAIN" reload:"true" usage:"Google Workspace domain (hd claim) whose users may sign in"`
+	GoogleAPIKey                         string `key:"google_api_key" env:"GOOGLE_API_KEY" secret:"true"`
+	GoogleClientID                       string `key:"google_client_id" env:"GOOGLE_CLIENT_ID" reload:"true"`
+	GoogleClientSecret                   string `key:"google_client_secret" env:"GOOGLE_CLIENT_SECRET" secret:"true" reload:"true"`
+	GoogleRedirectURL                    string `key:"google_redirect_url" env:"GOOGLE_REDIRECT_URL" reload:"true"`
+	GoogleServiceAccountCredentialsFile  string `key:"google_service_account_credentials_file" env:"GOOGLE_SERVICE_ACCOUNT_CREDENTIALS_FILE" default:"./service_account.json" reload:"true"`
+	GoogleServiceAccountImpersonatedUser string `key:"google_service_account_impersonated_user" env:"GOOGLE_SERVICE_ACCOUNT_IMPERSONATED_USER" reload:"true"` // email
+
+	LogLevel        string `key:"log_level" env:"LOG_LEVEL" default:"info" reload:"true"`
+	ServiceName     string `key:"service_name" env:"OTEL_SERVICE_NAME" default:"groom"`
+	TracingExporter string `key:"tracing_exporter" env:"OTEL_TRACES_EXPORTER" default:"none"` // "otlp", "stdout" ou "none"
+	TracingEndpoint string `key:"tracing_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	MetricsExporter string `key:"metrics_exporter" env:"OTEL_METRICS_EXPORTER" default:"none"` // "otlp", "stdout" ou "none"
+
+	ServerReadTimeout   time.Duration `key:"server_read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s"`
+	ServerWriteTimeout  time.Duration `key:"server_write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	ServerIdleTimeout   time.Duration `key:"server_idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s"`
+	ShutdownDrainPeriod time.Duration `key:"shutdown_drain_period" env:"SHUTDOWN_DRAIN_PERIOD" default:"5s"`
+	ShutdownTimeout     time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+
+	ConfigWatchInterval time.Duration `key:"config_watch_interval" env:"CONFIG_WATCH_INTERVAL" default:"10s" usage:"How often the config and credentials files are checked for changes (0 disables)"`
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
-	}
-	return defaultValue
+	HealthCheckInterval     time.Duration `key:"health_check_interval" env:"HEALTH_CHECK_INTERVAL" default:"15s"`
+	HealthCheckTimeout      time.Duration `key:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"5s"`
+	MeetHealthCheckInterval time.Duration `key:"meet_health_check_interval" env:"MEET_HEALTH_CHECK_INTERVAL" default:"5m"`
 }
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// MinSigningKeyLength est la taille minimale, en octets, d'une clé de signature de session.
+const MinSigningKeyLength = 32
+
+// Valeurs par défaut connues, refusées hors du mode démo
+var weakSecrets = []string{"secret", "changeme", "change-me", "password", "groom"}
+
+// DecodeKey décode une clé encodée en base64 (standard ou URL, avec ou sans padding).
+func DecodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if key, err := encoding.DecodeString(encoded); err == nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("not valid base64")
+}
+
+func validateSessionKeys(cfg Config) Errors {
+	var errs Errors
+
+	if len(cfg.SessionSigningKeys) == 0 {
+		if !cfg.DemoMode {
+			errs = append(errs, fmt.Errorf("session_signing_keys is required when web_ui_enabled is true (generate one with: openssl rand -base64 32)"))
+		}
+		return errs
+	}
+
+	for i, encoded := range cfg.SessionSigningKeys {
+		for _, weak := range weakSecrets {
+			if strings.EqualFold(strings.TrimSpace(encoded), weak) && !cfg.DemoMode {
+				errs = append(errs, fmt.Errorf("session_signing_keys[%d] is a well-known default secret", i))
+			}
+		}
+		key, err := DecodeKey(encoded)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session_signing_keys[%d]: %w", i, err))
+			continue
+		}
+		if len(key) < MinSigningKeyLength && !cfg.DemoMode {
+			errs = append(errs, fmt.Errorf("session_signing_keys[%d] is too short: %d bytes, at least %d required", i, len(key), MinSigningKeyLength))
+		}
+	}
+
+	if len(cfg.SessionEncryptionKeys) > 0 && len(cfg.SessionEncryptionKeys) != len(cfg.SessionSigningKeys) {
+		errs = append(errs, fmt.Errorf("session_encryption_keys must contain one key per signing key (%d), got %d", len(cfg.SessionSigningKeys), len(cfg.SessionEncryptionKeys)))
+	}
+	for i, encoded := range cfg.SessionEncryptionKeys {
+		key, err := DecodeKey(encoded)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("session_encryption_keys[%d]: %w", i, err))
+			continue
+		}
+		if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			errs = append(errs, fmt.Errorf("session_encryption_keys[%d] must be 16, 24 or 32 bytes, got %d", i, len(key)))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv désigne le fichier de configuration quand le flag --config n'est pas fourni.
+const ConfigFileEnv = "GROOM_CONFIG"
+
+// Errors regroupe tous les problèmes rencontrés au chargement et à la validation.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+type field struct {
+	name   string // nom du champ Go
+	key    string
+	env    string
+	secret bool
+	tag    reflect.StructTag
+	value  reflect.Value
+}
+
+func fields(cfg *Config) []field {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+
+	result := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if structField.Tag.Get("key") == "-" {
+			continue
+		}
+		result = append(result, field{
+			name:   structField.Name,
+			key:    structField.Tag.Get("key"),
+			env:    structField.Tag.Get("env"),
+			secret: structField.Tag.Get("secret") == "true",
+			tag:    structField.Tag,
+			value:  v.Field(i),
+		})
+	}
+	return result
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// newFlagSet déclare --config et un flag texte par champ ; les valeurs sont converties après coup par setValue.
+func newFlagSet(name string) (*flag.FlagSet, *string, map[string]*string) {
+	var cfg Config
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv(ConfigFileEnv), "Configuration file (.yaml, .yml or .toml, env "+ConfigFileEnv+")")
+
+	values := make(map[string]*string)
+	for _, f := range fields(&cfg) {
+		usage := f.tag.Get("usage")
+		if f.env != "" {
+			usage = strings.TrimSpace(usage + " (env " + f.env + ")")
+		}
+		values[flagName(f.key)] = flagSet.String(flagName(f.key), f.tag.Get("default"), usage)
+	}
+	return flagSet, configFile, values
+}
+
+// Load construit la configuration à partir des valeurs par défaut, du fichier, de l'environnement
+// et des flags de args, puis la valide. L'erreur retournée est de type Errors et liste tous les problèmes.
+func Load(name string, args []string) (Config, error) {
+	var cfg Config
+	var errs Errors
+
+	flagSet, configFile, flagValues := newFlagSet(name)
+	flagSet.SetOutput(io.Discard)
+	if err := flagSet.Parse(args); err != nil {
+		return cfg, Errors{err}
+	}
+
+	// Valeurs par défaut
+	for _, f := range fields(&cfg) {
+		if def, ok := f.tag.Lookup("default"); ok {
+			if err := setValue(f.value, def); err != nil {
+				errs = append(errs, fmt.Errorf("default for %s: %w", f.key, err))
+			}
+		}
+	}
+
+	// Fichier de configuration
+	if *configFile != "" {
+		cfg.File = *configFile
+		errs = append(errs, loadFile(&cfg, *configFile)...)
+	}
+
+	// Variables d'environnement
+	for _, f := range fields(&cfg) {
+		if f.env == "" {
+			continue
+		}
+		if value, exists := os.LookupEnv(f.env); exists {
+			if err := setValue(f.value, value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+	}
+
+	// Flags explicitement fournis
+	byKey := make(map[string]field)
+	for _, f := range fields(&cfg) {
+		byKey[flagName(f.key)] = f
+	}
+	flagSet.Visit(func(fl *flag.Flag) {
+		f, ok := byKey[fl.Name]
+		if !ok {
+			return
+		}
+		if err := setValue(f.value, *flagValues[fl.Name]); err != nil {
+			errs = append(errs, fmt.Errorf("--%s: %w", fl.Name, err))
+		}
+	})
+
+	errs = append(errs, cfg.Validate()...)
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// Usage décrit les flags acceptés par Load.
+func Usage(w io.Writer, name string) {
+	flagSet, _, _ := newFlagSet(name)
+	flagSet.SetOutput(w)
+	flagSet.PrintDefaults()
+}
+
+func loadFile(cfg *Config, path string) Errors {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Errors{fmt.Errorf("reading config file: %w", err)}
+	}
+
+	values := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return Errors{fmt.Errorf("config file %s: unsupported format (expected .yaml, .yml or .toml)", path)}
+	}
+	if err != nil {
+		return Errors{fmt.Errorf("parsing config file %s: %w", path, err)}
+	}
+
+	byKey := make(map[string]field)
+	for _, f := range fields(cfg) {
+		byKey[f.key] = f
+	}
+
+	var errs Errors
+	for key, raw := range values {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file %s: unknown key %q", path, key))
+			continue
+		}
+		if err := setValue(f.value, fileValueString(raw)); err != nil {
+			errs = append(errs, fmt.Errorf("config file %s: %s: %w", path, key, err))
+		}
+	}
+	return errs
+}
+
+// fileValueString ramène une valeur YAML/TOML à la représentation texte des variables d'environnement.
+func fileValueString(raw any) string {
+	switch value := raw.(type) {
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case v.Type() == durationType:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(duration))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(number))
+	case v.Kind() == reflect.Bool:
+		boolean, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(boolean)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return errors.New("unsupported field type " + v.Type().String())
+	}
+	return nil
+}
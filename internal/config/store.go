@@ -0,0 +1,52 @@
+package config
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Store partage la configuration courante entre les requêtes ; elle peut être remplacée à chaud.
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+func NewStore(cfg Config) *Store {
+	store := &Store{}
+	store.current.Store(&cfg)
+	return store
+}
+
+func (s *Store) Get() Config {
+	return *s.current.Load()
+}
+
+func (s *Store) Set(cfg Config) {
+	s.current.Store(&cfg)
+}
+
+// Changes décrit la différence entre deux configurations, par clé.
+type Changes struct {
+	Applied         []string // clés rechargées à chaud
+	RestartRequired []string // clés modifiées qui ne prendront effet qu'au redémarrage
+}
+
+// Merge applique à current les valeurs rechargeables de next ; les autres sont conservées et signalées.
+func Merge(current, next Config) (Config, Changes) {
+	var changes Changes
+	merged := current
+
+	nextFields := fields(&next)
+	for i, f := range fields(&merged) {
+		nextValue := nextFields[i].value
+		if reflect.DeepEqual(f.value.Interface(), nextValue.Interface()) {
+			continue
+		}
+		if f.tag.Get("reload") == "true" {
+			f.value.Set(nextValue)
+			changes.Applied = append(changes.Applied, f.key)
+		} else {
+			changes.RestartRequired = append(changes.RestartRequired, f.key)
+		}
+	}
+	return merged, changes
+}
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate vérifie l'ensemble de la configuration et renvoie tous les problèmes trouvés.
+// Seuls les paramètres utiles aux fonctionnalités activées sont exigés.
+func (cfg Config) Validate() Errors {
+	var errs Errors
+	require := func(value, key string, reason string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, fmt.Errorf("%s is required%s", key, reason))
+		}
+	}
+
+	if !cfg.WebUIEnabled && !cfg.APIEnabled {
+		errs = append(errs, fmt.Errorf("at least one of web_ui_enabled or api_enabled must be true"))
+	}
+
+	// Serveur
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("port must be a number between 1 and 65535, got %q", cfg.Port))
+	}
+
+	// TLS et certificats clients
+	if (cfg.TLSCertFile == "") != (cfg.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("tls_cert_file and tls_key_file must be set together"))
+	}
+	for _, file := range []struct{ key, path string }{
+		{"tls_cert_file", cfg.TLSCertFile},
+		{"tls_key_file", cfg.TLSKeyFile},
+		{"tls_client_ca_file", cfg.TLSClientCAFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if info, err := os.Stat(file.path); err != nil || info.IsDir() {
+			errs = append(errs, fmt.Errorf("%s %q is not a readable file", file.key, file.path))
+		}
+	}
+	if cfg.TLSClientCAFile != "" && cfg.TLSCertFile == "" {
+		errs = append(errs, fmt.Errorf("tls_client_ca_file requires tls_cert_file and tls_key_file"))
+	}
+	if len(cfg.TLSClientIdentityMapping) > 0 && cfg.TLSClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("tls_client_identity_mapping requires tls_client_ca_file"))
+	}
+	for _, entry := range cfg.TLSClientIdentityMapping {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 || !strings.Contains(entry[:i], ":") || strings.TrimSpace(entry[i+1:]) == "" {
+			errs = append(errs, fmt.Errorf("tls_client_identity_mapping entry %q must be type:value=scope", entry))
+		}
+	}
+	if cfg.TLSCertFile != "" && cfg.TLSReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("tls_reload_interval must be a positive duration"))
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			errs = append(errs, fmt.Errorf("trusted_proxies: %q is neither an IP address nor a CIDR", proxy))
+		}
+	}
+	if cfg.HSTSMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("hsts_max_age must not be negative"))
+	}
+
+	// Base de données
+	require(cfg.DatabaseURL, "database_url", "")
+	if cfg.DatabaseURL != "" {
+		if u, err := url.Parse(cfg.DatabaseURL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+			errs = append(errs, fmt.Errorf("database_url must be a postgres:// URL"))
+		}
+	}
+	if info, err := os.Stat(cfg.DatabaseMigrationPath); err != nil || !info.IsDir() {
+		errs = append(errs, fmt.Errorf("database_migration_path %q is not a readable directory", cfg.DatabaseMigrationPath))
+	}
+	if cfg.DatabaseMaxOpenConns < 0 || cfg.DatabaseMaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("database_max_open_conns and database_max_idle_conns must not be negative"))
+	}
+
+	// Compte de service Google Meet, utilisé par l'interface comme par l'API
+	if info, err := os.Stat(cfg.GoogleServiceAccountCredentialsFile); err != nil || info.IsDir() {
+		errs = append(errs, fmt.Errorf("google_service_account_credentials_file %q is not a readable file", cfg.GoogleServiceAccountCredentialsFile))
+	}
+	require(cfg.GoogleServiceAccountImpersonatedUser, "google_service_account_impersonated_user", "")
+	if cfg.GoogleServiceAccountImpersonatedUser != "" {
+		if _, err := mail.ParseAddress(cfg.GoogleServiceAccountImpersonatedUser); err != nil {
+			errs = append(errs, fmt.Errorf("google_service_account_impersonated_user must be an email address"))
+		}
+	}
+
+	// Connexion Google des navigateurs
+	if cfg.WebUIEnabled {
+		reason := " when web_ui_enabled is true"
+		if cfg.GoogleWorkspaceDomain == "" && len(cfg.SignInAllowedDomains) == 0 && len(cfg.SignInAllowedEmails) == 0 {
+			errs = append(errs, fmt.Errorf("google_workspace_domain, signin_allowed_domains or signin_allowed_emails is required%s", reason))
+		}
+		for _, list := range []struct {
+			key    string
+			emails []string
+		}{
+			{"signin_allowed_emails", cfg.SignInAllowedEmails},
+			{"signin_denied_emails", cfg.SignInDeniedEmails},
+		} {
+			for _, email := range list.emails {
+				if _, err := mail.ParseAddress(email); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %q is not an email address", list.key, email))
+				}
+			}
+		}
+		require(cfg.GoogleClientID, "google_client_id", reason)
+		require(cfg.GoogleClientSecret, "google_client_secret", reason)
+		require(cfg.GoogleRedirectURL, "google_redirect_url", reason)
+		if cfg.GoogleRedirectURL != "" {
+			if u, err := url.Parse(cfg.GoogleRedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+				errs = append(errs, fmt.Errorf("google_redirect_url must be an absolute URL"))
+			}
+		}
+
+		// Session
+		errs = append(errs, validateSessionKeys(cfg)...)
+		if !slices.Contains([]string{"lax", "strict", "none"}, strings.ToLower(cfg.SessionCookieSameSite)) {
+			errs = append(errs, fmt.Errorf("session_cookie_same_site must be one of lax, strict, none"))
+		}
+		if strings.EqualFold(cfg.SessionCookieSameSite, "none") && !cfg.SessionCookieSecure {
+			errs = append(errs, fmt.Errorf("session_cookie_same_site=none requires session_cookie_secure=true"))
+		}
+		if cfg.SessionMaxAge <= 0 {
+			errs = append(errs, fmt.Errorf("session_max_age must be a positive duration"))
+		}
+		if cfg.SessionCleanupInterval <= 0 {
+			errs = append(errs, fmt.Errorf("session_cleanup_interval must be a positive duration"))
+		}
+		if !slices.Contains([]string{"viewer", "editor", "admin", "none"}, strings.ToLower(cfg.RBACDefaultRole)) {
+			errs = append(errs, fmt.Errorf("rbac_default_role must be one of viewer, editor, admin, none"))
+		}
+		for _, email := range cfg.AdminEmails {
+			if _, err := mail.ParseAddress(email); err != nil {
+				errs = append(errs, fmt.Errorf("admin_emails: %q is not an email address", email))
+			}
+		}
+	}
+
+	// Rooms
+	if !slices.Contains([]string{"public", "domain", "restricted"}, cfg.RoomDefaultAccessPolicy) {
+		errs = append(errs, fmt.Errorf("room_default_access_policy must be one of public, domain, restricted"))
+	}
+	if cfg.RoomMaxPerUser < 0 || cfg.RoomMaxPerTeam < 0 {
+		errs = append(errs, fmt.Errorf("room_max_per_user and room_max_per_team must not be negative"))
+	}
+
+	// Notifications
+	if cfg.NotifyWebhookURL != "" {
+		if u, err := url.Parse(cfg.NotifyWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("notify_webhook_url must be an absolute http(s) URL"))
+		}
+	}
+	if cfg.NotifyWebhookSecret != "" {
+		if key, err := DecodeKey(cfg.NotifyWebhookSecret); err != nil {
+			errs = append(errs, fmt.Errorf("notify_webhook_secret: %w", err))
+		} else if len(key) < MinSigningKeyLength {
+			errs = append(errs, fmt.Errorf("notify_webhook_secret is too short: %d bytes, at least %d required", len(key), MinSigningKeyLength))
+		}
+	}
+	if cfg.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.SMTPAddr); err != nil {
+			errs = append(errs, fmt.Errorf("smtp_addr must be host:port: %w", err))
+		}
+		require(cfg.SMTPFrom, "smtp_from", " when smtp_addr is set")
+		if cfg.SMTPFrom != "" {
+			if _, err := mail.ParseAddress(cfg.SMTPFrom); err != nil {
+				errs = append(errs, fmt.Errorf("smtp_from: %q is not an email address", cfg.SMTPFrom))
+			}
+		}
+	}
+
+	for i, encoded := range cfg.GuestLinkSigningKeys {
+		if key, err := DecodeKey(encoded); err != nil {
+			errs = append(errs, fmt.Errorf("guest_link_signing_keys[%d]: %w", i, err))
+		} else if len(key) < MinSigningKeyLength {
+			errs = append(errs, fmt.Errorf("guest_link_signing_keys[%d] is too short: %d bytes, at least %d required", i, len(key), MinSigningKeyLength))
+		}
+	}
+	if len(cfg.GuestLinkSigningKeys) > 0 && cfg.GuestLinkMaxTTL <= 0 {
+		errs = append(errs, fmt.Errorf("guest_link_max_ttl must be a positive duration"))
+	}
+	if cfg.PublicURL != "" {
+		if u, err := url.Parse(cfg.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("public_url must be an absolute URL"))
+		}
+	}
+
+	// Limitation de débit
+	if !slices.Contains([]string{"memory", "postgres"}, cfg.RateLimitBackend) {
+		errs = append(errs, fmt.Errorf("rate_limit_backend must be one of memory, postgres"))
+	}
+	if cfg.RateLimitWindow <= 0 {
+		errs = append(errs, fmt.Errorf("rate_limit_window must be a positive duration"))
+	}
+	if cfg.RateLimitPerIP < 0 || cfg.RateLimitPerAPIKey < 0 || cfg.RateLimitPerUser < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit_per_ip, rate_limit_per_api_key and rate_limit_per_user must not be negative"))
+	}
+
+	// Tokens JWT des services
+	if cfg.JWTJWKSURL != "" || cfg.JWTJWKSFile != "" {
+		reason := " when jwt_jwks_url or jwt_jwks_file is set"
+		if cfg.JWTJWKSURL != "" && cfg.JWTJWKSFile != "" {
+			errs = append(errs, fmt.Errorf("jwt_jwks_url and jwt_jwks_file are mutually exclusive"))
+		}
+		if cfg.JWTJWKSURL != "" {
+			if u, err := url.Parse(cfg.JWTJWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("jwt_jwks_url must be an absolute http(s) URL"))
+			}
+		}
+		if cfg.JWTJWKSFile != "" {
+			if info, err := os.Stat(cfg.JWTJWKSFile); err != nil || info.IsDir() {
+				errs = append(errs, fmt.Errorf("jwt_jwks_file %q is not a readable file", cfg.JWTJWKSFile))
+			}
+		}
+		if len(cfg.JWTIssuers) == 0 {
+			errs = append(errs, fmt.Errorf("jwt_issuers is required%s", reason))
+		}
+		if len(cfg.JWTAudiences) == 0 {
+			errs = append(errs, fmt.Errorf("jwt_audiences is required%s", reason))
+		}
+		require(cfg.JWTScopeClaim, "jwt_scope_claim", reason)
+		for _, entry := range cfg.JWTScopeMapping {
+			if value, scope, ok := strings.Cut(entry, "="); !ok || strings.TrimSpace(value) == "" || strings.TrimSpace(scope) == "" {
+				errs = append(errs, fmt.Errorf("jwt_scope_mapping entry %q must be value=scope", entry))
+			}
+		}
+		if cfg.JWTJWKSRefreshInterval <= 0 {
+			errs = append(errs, fmt.Errorf("jwt_jwks_refresh_interval must be a positive duration"))
+		}
+	}
+
+	// Observabilité
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, strings.ToLower(cfg.LogLevel)) {
+		errs = append(errs, fmt.Errorf("log_level must be one of debug, info, warn, error"))
+	}
+	if !slices.Contains([]string{"none", "stdout", "otlp"}, cfg.TracingExporter) {
+		errs = append(errs, fmt.Errorf("tracing_exporter must be one of none, stdout, otlp"))
+	}
+	if !slices.Contains([]string{"none", "stdout", "otlp"}, cfg.MetricsExporter) {
+		errs = append(errs, fmt.Errorf("metrics_exporter must be one of none, stdout, otlp"))
+	}
+
+	// Délais
+	for _, d := range []struct {
+		key   string
+		value time.Duration
+	}{
+		{"database_query_timeout", cfg.DatabaseQueryTimeout},
+		{"meet_call_timeout", cfg.MeetCallTimeout},
+		{"server_read_timeout", cfg.ServerReadTimeout},
+		{"server_write_timeout", cfg.ServerWriteTimeout},
+		{"server_idle_timeout", cfg.ServerIdleTimeout},
+		{"shutdown_timeout", cfg.ShutdownTimeout},
+		{"health_check_interval", cfg.HealthCheckInterval},
+		{"health_check_timeout", cfg.HealthCheckTimeout},
+		{"meet_health_check_interval", cfg.MeetHealthCheckInterval},
+	} {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration", d.key))
+		}
+	}
+	if cfg.ShutdownDrainPeriod < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_drain_period must not be negative"))
+	}
+	if cfg.ConfigWatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("config_watch_interval must not be negative"))
+	}
+
+	return errs
+}
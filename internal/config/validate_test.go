@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// key renvoie une clé base64 de n octets.
+func key(n int) string {
+	return base64.StdEncoding.EncodeToString(make([]byte, n))
+}
+
+// validConfig renvoie une configuration complète et valide : les valeurs par défaut plus les paramètres obligatoires.
+func validConfig(t *testing.T) Config {
+	t.Helper()
+	var cfg Config
+	for _, f := range fields(&cfg) {
+		if def, ok := f.tag.Lookup("default"); ok {
+			if err := setValue(f.value, def); err != nil {
+				t.Fatalf("default for %s: %v", f.key, err)
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	credentials := filepath.Join(dir, "credentials.json")
+	if err := os.WriteFile(credentials, []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg.DatabaseURL = "postgres://groom@localhost/groom"
+	cfg.DatabaseMigrationPath = dir
+	cfg.GoogleServiceAccountCredentialsFile = credentials
+	cfg.GoogleServiceAccountImpersonatedUser = "robot@example.test"
+	cfg.GoogleWorkspaceDomain = "example.test"
+	cfg.GoogleClientID = "client-id"
+	cfg.GoogleClientSecret = "client-secret"
+	cfg.GoogleRedirectURL = "https://groom.example.test/auth/callback"
+	cfg.SessionSigningKeys = []string{key(32)}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		wantErr string // vide : la configuration doit être valide
+	}{
+		{"valid", func(cfg *Config) {}, ""},
+		{"web UI and API disabled", func(cfg *Config) { cfg.WebUIEnabled, cfg.APIEnabled = false, false }, "at least one of web_ui_enabled or api_enabled"},
+		{"invalid port", func(cfg *Config) { cfg.Port = "70000" }, "port must be a number"},
+		{"missing database URL", func(cfg *Config) { cfg.DatabaseURL = "" }, "database_url is required"},
+		{"non-postgres database URL", func(cfg *Config) { cfg.DatabaseURL = "mysql://localhost/groom" }, "database_url must be a postgres:// URL"},
+
+		// Clés de session
+		{"missing session key", func(cfg *Config) { cfg.SessionSigningKeys = nil }, "session_signing_keys is required"},
+		{"weak session key", func(cfg *Config) { cfg.SessionSigningKeys = []string{"changeme"} }, "well-known default secret"},
+		{"short session key", func(cfg *Config) { cfg.SessionSigningKeys = []string{key(31)} }, "session_signing_keys[0] is too short"},
+		{"session key not base64", func(cfg *Config) { cfg.SessionSigningKeys = []string{"not base64!"} }, "session_signing_keys[0]: not valid base64"},
+		{"rotated session keys", func(cfg *Config) { cfg.SessionSigningKeys = []string{key(32), key(64)} }, ""},
+		{"encryption key count mismatch", func(cfg *Config) { cfg.SessionEncryptionKeys = []string{key(32), key(32)} }, "one key per signing key"},
+		{"encryption key of invalid size", func(cfg *Config) { cfg.SessionEncryptionKeys = []string{key(20)} }, "must be 16, 24 or 32 bytes"},
+		{"API only needs no session key", func(cfg *Config) { cfg.WebUIEnabled, cfg.SessionSigningKeys = false, nil }, ""},
+
+		// Mode démo
+		{"demo mode without session key", func(cfg *Config) { cfg.DemoMode, cfg.SessionSigningKeys = true, nil }, ""},
+		{"demo mode with weak session key", func(cfg *Config) { cfg.DemoMode, cfg.SessionSigningKeys = true, []string{"secret"} }, ""},
+		{"demo mode with short session key", func(cfg *Config) { cfg.DemoMode, cfg.SessionSigningKeys = true, []string{key(8)} }, ""},
+
+		// Autres clés HMAC
+		{"short guest link key", func(cfg *Config) { cfg.GuestLinkSigningKeys = []string{key(16)} }, "guest_link_signing_keys[0] is too short"},
+		{"short webhook secret", func(cfg *Config) { cfg.NotifyWebhookSecret = key(16) }, "notify_webhook_secret is too short"},
+		{"webhook secret", func(cfg *Config) { cfg.NotifyWebhookSecret = key(32) }, ""},
+
+		// Tokens JWT
+		{"JWKS without issuers", func(cfg *Config) {
+			cfg.JWTJWKSURL, cfg.JWTAudiences = "https://idp.example.test/jwks.json", []string{"groom"}
+		}, "jwt_issuers is required"},
+		{"JWKS without audiences", func(cfg *Config) {
+			cfg.JWTJWKSURL, cfg.JWTIssuers = "https://idp.example.test/jwks.json", []string{"https://idp.example.test"}
+		}, "jwt_audiences is required"},
+		{"JWKS URL and file", func(cfg *Config) {
+			cfg.JWTJWKSURL, cfg.JWTJWKSFile = "https://idp.example.test/jwks.json", cfg.GoogleServiceAccountCredentialsFile
+			cfg.JWTIssuers, cfg.JWTAudiences = []string{"https://idp.example.test"}, []string{"groom"}
+		}, "mutually exclusive"},
+		{"invalid JWT scope mapping", func(cfg *Config) {
+			cfg.JWTJWKSURL = "https://idp.example.test/jwks.json"
+			cfg.JWTIssuers, cfg.JWTAudiences = []string{"https://idp.example.test"}, []string{"groom"}
+			cfg.JWTScopeMapping = []string{"rooms.read"}
+		}, "must be value=scope"},
+
+		// Notifications
+		{"SMTP without sender", func(cfg *Config) { cfg.SMTPAddr = "smtp.example.test:587" }, "smtp_from is required"},
+		{"SMTP address without port", func(cfg *Config) { cfg.SMTPAddr, cfg.SMTPFrom = "smtp.example.test", "groom@example.test" }, "smtp_addr must be host:port"},
+		{"relative webhook URL", func(cfg *Config) { cfg.NotifyWebhookURL = "/hooks" }, "notify_webhook_url must be an absolute http(s) URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.change(&cfg)
+			errs := cfg.Validate()
+
+			if tt.wantErr == "" {
+				if len(errs) > 0 {
+					t.Fatalf("Validate() = %v, want no error", errs)
+				}
+				return
+			}
+			if !strings.Contains(errs.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want an error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateReportsAllErrors vérifie que la validation liste tous les problèmes, pas seulement le premier.
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.DatabaseURL = ""
+	cfg.SessionSigningKeys = []string{key(8)}
+	cfg.RateLimitBackend = "redis"
+
+	errs := cfg.Validate()
+	if len(errs) != 3 {
+		t.Fatalf("Validate() returned %d errors, want 3: %v", len(errs), errs)
+	}
+}
@@ -1,8 +1,15 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"groom/internal/config"
 	"log"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -12,11 +19,18 @@ import (
 
 var Database *sql.DB
 
-func InitDatabase(datasourceName string) {
-	database, err := sql.Open("pgx", datasourceName)
+func InitDatabase(cfg config.Config) {
+	database, err := sql.Open("pgx", cfg.DatabaseURL)
 	if err != nil {
 		log.Fatalf("Unable to connect to database: %v\n", err)
 	}
+
+	// Pool de connexions
+	database.SetMaxOpenConns(cfg.DatabaseMaxOpenConns)
+	database.SetMaxIdleConns(cfg.DatabaseMaxIdleConns)
+	database.SetConnMaxLifetime(cfg.DatabaseConnMaxLifetime)
+	database.SetConnMaxIdleTime(cfg.DatabaseConnMaxIdleTime)
+
 	Database = database
 }
 
@@ -39,10 +53,50 @@ func RunMigrations(migrationsPath string, databaseName string) error {
 		return err
 	}
 
-	log.Println("Migrations applied successfully")
+	slog.Info("Migrations applied successfully")
 	return nil
 }
 
+// CheckMigrations compare la version appliquée en base à la dernière migration disponible.
+func CheckMigrations(ctx context.Context, database *sql.DB, migrationsPath string) error {
+	var version int
+	var dirty bool
+	err := database.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		return fmt.Errorf("reading schema_migrations: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+
+	latest, err := latestMigrationVersion(migrationsPath)
+	if err != nil {
+		return err
+	}
+	if version < latest {
+		return fmt.Errorf("schema is at version %d, expected %d", version, latest)
+	}
+	return nil
+}
+
+func latestMigrationVersion(migrationsPath string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(migrationsPath, "*.up.sql"))
+	if err != nil {
+		return 0, err
+	}
+
+	latest := 0
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
 func Connect(databaseURL string) (*sql.DB, error) {
 	return sql.Open("pgx", databaseURL)
 }
@@ -3,12 +3,20 @@ package googleapi
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"groom/internal/config"
-	"log"
+	"groom/internal/telemetry"
+	"log/slog"
+	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google" // Importer le package meet/v2
 	"golang.org/x/oauth2/jwt"
@@ -16,37 +24,107 @@ import (
 	"google.golang.org/api/option"
 )
 
-var UserOAuthConfig *oauth2.Config
-var ServiceAccountOAuthConfig *jwt.Config
+var tracer = otel.Tracer("groom/internal/google")
 
-type MeetClient struct {
+// startSpan ouvre un span client pour un appel à l'API Google Meet et borne sa durée par le timeout du client.
+// La fonction retournée termine le span et libère le contexte.
+func (mc *MeetClient) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span, func(error)) {
+	ctx, span := tracer.Start(ctx, "meet."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	ctx, cancel := context.WithTimeout(ctx, mc.state.Load().timeout)
+	return ctx, span, func(err error) {
+		cancel()
+		telemetry.EndSpan(span, err)
+	}
+}
+
+var userOAuthConfig atomic.Pointer[oauth2.Config]
+
+// UserOAuth renvoie la configuration OAuth utilisateur courante ; elle peut être remplacée à chaud.
+func UserOAuth() *oauth2.Config {
+	return userOAuthConfig.Load()
+}
+
+// meetState regroupe ce qui dépend de la configuration et des credentials du compte de service.
+type meetState struct {
 	service *meet.Service
-	cache   *cache.Cache
+	timeout time.Duration
+}
+
+type MeetClient struct {
+	state atomic.Pointer[meetState]
+	cache *cache.Cache
 }
 
 var MeetService *MeetClient
 
 func InitUserOAuth(cfg config.Config) {
-	UserOAuthConfig = &oauth2.Config{
+	userOAuthConfig.Store(&oauth2.Config{
 		ClientID:     cfg.GoogleClientID,
 		ClientSecret: cfg.GoogleClientSecret,
 		RedirectURL:  cfg.GoogleRedirectURL,
 		Scopes: []string{
+			"openid",
 			"https://www.googleapis.com/auth/userinfo.email",
 			"https://www.googleapis.com/auth/userinfo.profile",
 		},
 		Endpoint: google.Endpoint,
+	})
+}
+
+func InitServiceAccountServices(cfg config.Config) error {
+	state, err := newMeetState(cfg)
+	if err != nil {
+		return err
 	}
+
+	// Initialisation du MeetClient exporté
+	MeetService = &MeetClient{
+		cache: cache.New(5*time.Second, 15*time.Minute),
+	}
+	MeetService.state.Store(state)
+	return nil
+}
+
+// Reload reconstruit le client Meet à partir de la configuration et du fichier de credentials courants.
+// Les appels déjà en cours se terminent avec l'ancien client ; en cas d'erreur, l'ancien client est conservé.
+func (mc *MeetClient) Reload(cfg config.Config) error {
+	state, err := newMeetState(cfg)
+	if err != nil {
+		return err
+	}
+	mc.state.Store(state)
+	return nil
 }
 
-func InitServiceAccountServices(cfg config.Config) {
-	ctx := context.Background()
+func (mc *MeetClient) service() *meet.Service {
+	return mc.state.Load().service
+}
 
-	serviceAccountFile := "./service_account.json"
+func newMeetState(cfg config.Config) (*meetState, error) {
+	client, err := serviceAccountClient(cfg, meet.MeetingsSpaceCreatedScope, meet.MeetingsSpaceReadonlyScope)
+	if err != nil {
+		return nil, err
+	}
+
+	meetService, err := meet.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Meet client: %w", err)
+	}
+
+	return &meetState{
+		service: meetService,
+		timeout: cfg.MeetCallTimeout,
+	}, nil
+}
+
+// serviceAccountClient renvoie un client HTTP authentifié par le compte de service, qui agit au nom de
+// l'utilisateur impersonné (délégation au niveau du domaine) avec les scopes demandés.
+func serviceAccountClient(cfg config.Config, scopes ...string) (*http.Client, error) {
+	serviceAccountFile := cfg.GoogleServiceAccountCredentialsFile
 
 	credentialsJSON, err := os.ReadFile(serviceAccountFile)
 	if err != nil {
-		log.Fatalf("Unable to read service account file: %v", err)
+		return nil, fmt.Errorf("unable to read service account file: %w", err)
 	}
 
 	// Désérialiser le fichier JSON
@@ -56,54 +134,48 @@ func InitServiceAccountServices(cfg config.Config) {
 		TokenURL    string `json:"token_uri"`
 	}
 	if err := json.Unmarshal(credentialsJSON, &credentials); err != nil {
-		log.Fatalf("Unable to unmarshal service account JSON: %v", err)
+		return nil, fmt.Errorf("unable to unmarshal service account JSON: %w", err)
 	}
 
 	// Spécifiez l'utilisateur à impersonner (un utilisateur du domaine Google Workspace)
 	impersonatedUser := cfg.GoogleServiceAccountImpersonatedUser // L'utilisateur que vous voulez impersonner
 
 	// Configurer le compte de service pour agir en tant qu'utilisateur avec délégation
-	ServiceAccountOAuthConfig := &jwt.Config{
+	serviceAccountOAuthConfig := &jwt.Config{
 		Email:      credentials.ClientEmail,
 		PrivateKey: []byte(credentials.PrivateKey),
-		Scopes: []string{
-			meet.MeetingsSpaceCreatedScope,
-			meet.MeetingsSpaceReadonlyScope,
-		},
-		TokenURL: credentials.TokenURL,
-		Subject:  impersonatedUser, // Spécifiez l'utilisateur pour l'impersonation
-	}
-	client := ServiceAccountOAuthConfig.Client(context.Background())
-
-	meetService, err := meet.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		log.Fatalf("Unable to retrieve Calendar client: %v", err)
-	}
-
-	// Initialisation du MeetClient exporté
-	MeetService = &MeetClient{
-		service: meetService,
-		cache:   cache.New(5*time.Second, 15*time.Minute),
+		Scopes:     scopes,
+		TokenURL:   credentials.TokenURL,
+		Subject:    impersonatedUser, // Spécifiez l'utilisateur pour l'impersonation
 	}
-
+	client := serviceAccountOAuthConfig.Client(context.Background())
+	client.Transport = otelhttp.NewTransport(client.Transport)
+	return client, nil
 }
 
-func (mc *MeetClient) CheckMeetClient() error {
-	_, err := mc.service.ConferenceRecords.List().Do()
+func (mc *MeetClient) CheckMeetClient(ctx context.Context) (err error) {
+	ctx, _, end := mc.startSpan(ctx, "CheckMeetClient")
+	defer func() { end(err) }()
+
+	_, err = mc.service().ConferenceRecords.List().Context(ctx).Do()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (mc *MeetClient) GetSpace(spaceID string) (*meet.Space, error) {
+func (mc *MeetClient) GetSpace(ctx context.Context, spaceID string) (_ *meet.Space, err error) {
+	ctx, span, end := mc.startSpan(ctx, "GetSpace", attribute.String("meet.space_id", spaceID))
+	defer func() { end(err) }()
+
 	cacheKey := "meet_space_" + spaceID
 
 	if cachedSpace, found := mc.cache.Get(cacheKey); found {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
 		return cachedSpace.(*meet.Space), nil
 	}
 
-	space, err := mc.service.Spaces.Get(spaceID).Do()
+	space, err := mc.service().Spaces.Get(spaceID).Context(ctx).Do()
 
 	if err != nil {
 		return nil, err
@@ -114,8 +186,11 @@ func (mc *MeetClient) GetSpace(spaceID string) (*meet.Space, error) {
 	return space, nil
 }
 
-func (mc *MeetClient) CreateSpace() (*meet.Space, error) {
-	space, err := mc.service.Spaces.Create(&meet.Space{}).Do()
+func (mc *MeetClient) CreateSpace(ctx context.Context) (_ *meet.Space, err error) {
+	ctx, _, end := mc.startSpan(ctx, "CreateSpace")
+	defer func() { end(err) }()
+
+	space, err := mc.service().Spaces.Create(&meet.Space{}).Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -134,14 +209,19 @@ type ConferenceDTO struct {
 	Participants []ParticipantDTO `json:"participants"`
 }
 
-func (mc *MeetClient) ListActiveConferences() ([]*ConferenceDTO, error) {
+func (mc *MeetClient) ListActiveConferences(ctx context.Context) (_ []*ConferenceDTO, err error) {
+	// Le span englobe tous les appels ; chacun d'eux est borné séparément par le timeout du client
+	ctx, span := tracer.Start(ctx, "meet.ListActiveConferences")
+	defer func() { telemetry.EndSpan(span, err) }()
+
 	cacheKey := "meet_active_conferences"
 
 	if cachedConferences, found := mc.cache.Get(cacheKey); found {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
 		return cachedConferences.([]*ConferenceDTO), nil
 	}
 
-	activeConferences, err := mc.service.ConferenceRecords.List().Filter("end_time IS NULL").Do()
+	activeConferences, err := mc.listActiveConferenceRecords(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -154,9 +234,9 @@ func (mc *MeetClient) ListActiveConferences() ([]*ConferenceDTO, error) {
 			SpaceID: conference.Space,
 		}
 
-		participantsList, err := mc.service.ConferenceRecords.Participants.List(conference.Name).Filter("latest_end_time IS NULL").Do()
+		participantsList, err := mc.listParticipants(ctx, conference.Name)
 		if err != nil {
-			log.Printf("Failed to retrieve participants for conference %s: %v", conference.Name, err)
+			slog.WarnContext(ctx, "Failed to retrieve participants", slog.String("conference", conference.Name), slog.Any("error", err))
 		} else {
 			for _, participant := range participantsList.Participants {
 				participantDTO := ParticipantDTO{
@@ -173,3 +253,17 @@ func (mc *MeetClient) ListActiveConferences() ([]*ConferenceDTO, error) {
 
 	return conferencesDTO, nil
 }
+
+func (mc *MeetClient) listActiveConferenceRecords(ctx context.Context) (_ *meet.ListConferenceRecordsResponse, err error) {
+	ctx, _, end := mc.startSpan(ctx, "ListConferenceRecords")
+	defer func() { end(err) }()
+
+	return mc.service().ConferenceRecords.List().Filter("end_time IS NULL").Context(ctx).Do()
+}
+
+func (mc *MeetClient) listParticipants(ctx context.Context, conferenceName string) (_ *meet.ListParticipantsResponse, err error) {
+	ctx, _, end := mc.startSpan(ctx, "ListParticipants", attribute.String("meet.conference", conferenceName))
+	defer func() { end(err) }()
+
+	return mc.service().ConferenceRecords.Participants.List(conferenceName).Filter("latest_end_time IS NULL").Context(ctx).Do()
+}
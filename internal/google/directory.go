@@ -0,0 +1,80 @@
+package googleapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"groom/internal/config"
+	"groom/internal/telemetry"
+
+	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	admin "google.golang.org/api/admin/directory/v1"
+	"google.golang.org/api/option"
+)
+
+// GroupClient résout les groupes Google d'un utilisateur avec l'API Directory. Le compte de service doit
+// disposer de la délégation pour le scope admin.directory.group.readonly.
+type GroupClient struct {
+	service atomic.Pointer[admin.Service]
+	timeout atomic.Int64
+	cache   *cache.Cache
+}
+
+var GroupService *GroupClient
+
+func InitGroupService(cfg config.Config) error {
+	GroupService = &GroupClient{
+		cache: cache.New(5*time.Minute, 15*time.Minute),
+	}
+	return GroupService.Reload(cfg)
+}
+
+// Reload reconstruit le client Directory ; en cas d'erreur, l'ancien client est conservé.
+func (gc *GroupClient) Reload(cfg config.Config) error {
+	client, err := serviceAccountClient(cfg, admin.AdminDirectoryGroupReadonlyScope)
+	if err != nil {
+		return err
+	}
+	service, err := admin.NewService(context.Background(), option.WithHTTPClient(client))
+	if err != nil {
+		return fmt.Errorf("unable to create Directory client: %w", err)
+	}
+	gc.service.Store(service)
+	gc.timeout.Store(int64(cfg.MeetCallTimeout))
+	return nil
+}
+
+// UserGroups renvoie les emails (en minuscules) des groupes dont l'utilisateur est directement membre.
+// Le résultat est mis en cache quelques minutes.
+func (gc *GroupClient) UserGroups(ctx context.Context, email string) (_ []string, err error) {
+	ctx, span := tracer.Start(ctx, "directory.UserGroups", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { telemetry.EndSpan(span, err) }()
+
+	cacheKey := "groups_" + strings.ToLower(email)
+	if cached, found := gc.cache.Get(cacheKey); found {
+		span.SetAttributes(attribute.Bool("cache.hit", true))
+		return cached.([]string), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(gc.timeout.Load()))
+	defer cancel()
+
+	var groups []string
+	err = gc.service.Load().Groups.List().UserKey(email).Context(ctx).Pages(ctx, func(page *admin.Groups) error {
+		for _, group := range page.Groups {
+			groups = append(groups, strings.ToLower(group.Email))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gc.cache.Set(cacheKey, groups, cache.DefaultExpiration)
+	return groups, nil
+}
@@ -0,0 +1,53 @@
+package googleapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"google.golang.org/api/idtoken"
+)
+
+// Émetteurs acceptés pour les ID tokens Google
+var googleIssuers = map[string]bool{
+	"accounts.google.com":         true,
+	"https://accounts.google.com": true,
+}
+
+// Identity est l'utilisateur authentifié par un ID token OpenID Connect vérifié.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Domain        string // claim "hd" : domaine Google Workspace du compte, vide pour un compte personnel
+}
+
+// VerifyIDToken vérifie la signature et l'expiration de l'ID token, puis l'émetteur, l'audience (client ID)
+// et le nonce de la requête d'authentification. Les règles d'accès (domaines, emails) sont appliquées ensuite.
+func VerifyIDToken(ctx context.Context, rawIDToken, clientID, nonce string) (*Identity, error) {
+	payload, err := idtoken.Validate(ctx, rawIDToken, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if !googleIssuers[payload.Issuer] {
+		return nil, fmt.Errorf("unexpected ID token issuer %q", payload.Issuer)
+	}
+
+	claimNonce, _ := payload.Claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claimNonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID token nonce does not match")
+	}
+
+	identity := &Identity{Subject: payload.Subject}
+	identity.Email, _ = payload.Claims["email"].(string)
+	identity.EmailVerified, _ = payload.Claims["email_verified"].(bool)
+	identity.Name, _ = payload.Claims["name"].(string)
+	identity.Domain, _ = payload.Claims["hd"].(string)
+	if identity.Email == "" {
+		return nil, errors.New("ID token has no email claim")
+	}
+	return identity, nil
+}
@@ -0,0 +1,97 @@
+package guestlink
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"groom/internal/config"
+)
+
+// Paramètre de requête portant le jeton : /<slug>?guest=<jeton>
+const QueryParam = "guest"
+
+// Format d'un jeton : g1.<identifiant du lien>.<expiration unix>.<HMAC-SHA256>
+// La signature couvre aussi l'identifiant de la room, qui n'apparaît pas dans le jeton :
+// un lien présenté sur une autre room est refusé.
+const version = "g1"
+
+var (
+	ErrInvalid = errors.New("invalid guest link")
+	ErrExpired = errors.New("guest link expired")
+)
+
+// Signer signe les liens invités avec la clé la plus récente et les vérifie avec toutes les clés configurées.
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner renvoie nil si aucune clé n'est configurée : les liens invités sont alors désactivés.
+func NewSigner(cfg config.Config) (*Signer, error) {
+	if len(cfg.GuestLinkSigningKeys) == 0 {
+		return nil, nil
+	}
+	s := &Signer{}
+	for i, encoded := range cfg.GuestLinkSigningKeys {
+		key, err := config.DecodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("guest link signing key %d: %w", i, err)
+		}
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// Sign renvoie le jeton du lien id, valable pour la room roomID jusqu'à expiresAt.
+func (s *Signer) Sign(id, roomID int, expiresAt time.Time) string {
+	payload := version + "." + strconv.Itoa(id) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac(s.keys[0], payload, roomID))
+}
+
+// Verify vérifie la signature et l'expiration du jeton pour la room roomID et renvoie l'identifiant du lien.
+// L'état du lien (révocation, nombre d'utilisations) reste à vérifier en base.
+func (s *Signer) Verify(token string, roomID int) (int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return 0, ErrInvalid
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return 0, ErrInvalid
+	}
+
+	payload := strings.Join(parts[:3], ".")
+	valid := false
+	for _, key := range s.keys {
+		if hmac.Equal(signature, mac(key, payload, roomID)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return 0, ErrInvalid
+	}
+	if time.Now().After(time.Unix(expires, 0)) {
+		return id, ErrExpired
+	}
+	return id, nil
+}
+
+func mac(key []byte, payload string, roomID int) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(payload + "." + strconv.Itoa(roomID)))
+	return h.Sum(nil)
+}
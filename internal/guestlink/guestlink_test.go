@@ -0,0 +1,69 @@
+package guestlink
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"groom/internal/config"
+)
+
+func newSigner(t *testing.T, keys ...string) *Signer {
+	t.Helper()
+	signer, err := NewSigner(config.Config{GuestLinkSigningKeys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestVerify(t *testing.T) {
+	signer := newSigner(t, key('a'))
+	expiresAt := time.Now().Add(time.Hour)
+	token := signer.Sign(7, 3, expiresAt)
+	parts := strings.Split(token, ".")
+
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		roomID  int
+		wantErr error
+	}{
+		{"valid", signer, token, 3, nil},
+		{"signed with a previous key", newSigner(t, key('b'), key('a')), token, 3, nil},
+		{"foreign room", signer, token, 4, ErrInvalid},
+		{"tampered link ID", signer, strings.Join([]string{parts[0], "8", parts[2], parts[3]}, "."), 3, ErrInvalid},
+		{"tampered expiry", signer, strings.Join([]string{parts[0], parts[1], "9999999999", parts[3]}, "."), 3, ErrInvalid},
+		{"signature of another link", signer, strings.Join(parts[:3], ".") + "." + strings.Split(signer.Sign(8, 3, expiresAt), ".")[3], 3, ErrInvalid},
+		{"rotated-out key", newSigner(t, key('b')), token, 3, ErrInvalid},
+		{"expired", signer, signer.Sign(7, 3, time.Now().Add(-time.Second)), 3, ErrExpired},
+		{"unknown version", signer, "g2" + strings.TrimPrefix(token, "g1"), 3, ErrInvalid},
+		{"malformed", signer, "g1.7", 3, ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := tt.signer.Verify(tt.token, tt.roomID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id != 7 {
+				t.Fatalf("Verify() = %d, want 7", id)
+			}
+		})
+	}
+}
+
+func TestNewSignerWithoutKeys(t *testing.T) {
+	signer, err := NewSigner(config.Config{})
+	if err != nil || signer != nil {
+		t.Fatalf("NewSigner() = %v, %v, want guest links disabled", signer, err)
+	}
+}
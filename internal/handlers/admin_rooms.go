@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Libellés des politiques d'accès dans les formulaires
+var accessPolicyLabels = []struct{ Value, Label string }{
+	{models.AccessPublic, "Publique : toute personne ayant le lien"},
+	{models.AccessDomain, "Domaine : utilisateurs connectés"},
+	{models.AccessRestricted, "Restreinte : personnes et groupes autorisés"},
+}
+
+// GET /admin/rooms
+func ListRoomsAdminHTMLHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rooms, err := models.GetAllRooms(c.Request.Context(), db)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve rooms", "La liste des salles n'a pas pu être chargée.", err)
+			return
+		}
+
+		renderHTML(c, http.StatusOK, "admin_rooms.html", gin.H{
+			"csrfToken": csrfToken(c),
+			"rooms":     rooms,
+		})
+	}
+}
+
+// GET /admin/rooms/new
+func NewRoomFormHandler(cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminRoomForm(c, models.Room{AccessPolicy: cfgStore.Get().RoomDefaultAccessPolicy})(http.StatusOK, nil)
+	}
+}
+
+// POST /admin/rooms
+func CreateRoomFormHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room := models.Room{
+			Slug:         c.PostForm("slug"),
+			Name:         c.PostForm("name"),
+			Description:  c.PostForm("description"),
+			OwnerEmail:   c.GetString(PrincipalKey),
+			AccessPolicy: c.PostForm("access_policy"),
+		}
+
+		created, err := createRoom(c, db, meetService, room, roomQuota{}, nil, nil)
+		if err != nil {
+			respondRoomForm(c, adminRoomForm(c, room), "Error creating room", "La salle n'a pas pu être créée.", err, slog.String("slug", room.Slug))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Room created", slog.String("actor", actor(c)), slog.Int("room_id", created.ID), slog.String("slug", created.Slug))
+		c.Redirect(http.StatusSeeOther, "/admin/rooms")
+	}
+}
+
+// GET /admin/rooms/:id/edit
+func EditRoomFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoomPage(c, db)
+		if !ok {
+			return
+		}
+		adminRoomForm(c, *room)(http.StatusOK, nil)
+	}
+}
+
+// POST /admin/rooms/:id
+func UpdateRoomFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoomPage(c, db)
+		if !ok {
+			return
+		}
+		room.Slug = c.PostForm("slug")
+		room.Name = c.PostForm("name")
+		room.Description = c.PostForm("description")
+
+		_, err := changeRoom(c, db, room.ID, models.AuditRoomUpdate, func(current *models.Room) {
+			current.Slug, current.Name, current.Description = room.Slug, room.Name, room.Description
+		})
+		if err != nil {
+			respondRoomForm(c, adminRoomForm(c, *room), "Error updating room", "La salle n'a pas pu être modifiée.", err, slog.Int("room_id", room.ID))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/rooms")
+	}
+}
+
+// POST /admin/rooms/:id/rotate-space
+func RotateRoomSpaceFormHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoomPage(c, db)
+		if !ok {
+			return
+		}
+		if _, err := rotateRoomSpace(c, db, meetService, room); err != nil {
+			respondErrorPage(c, roomErrorStatus(err), "Error rotating room space", "L'espace Meet de la salle n'a pas pu être remplacé.", err, slog.Int("room_id", room.ID))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/rooms/"+strconv.Itoa(room.ID)+"/edit")
+	}
+}
+
+// POST /admin/rooms/:id/archive et POST /admin/rooms/:id/unarchive
+func ArchiveRoomFormHandler(db *sql.DB, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoomPage(c, db)
+		if !ok {
+			return
+		}
+		if _, err := setRoomArchived(c, db, room.ID, archived); err != nil {
+			respondErrorPage(c, roomErrorStatus(err), "Error archiving room", "La salle n'a pas pu être archivée ou rétablie.", err, slog.Int("room_id", room.ID))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/rooms")
+	}
+}
+
+// managedRoomPage fait comme managedRoom pour les pages HTML.
+func managedRoomPage(c *gin.Context, db *sql.DB) (*models.Room, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondErrorPage(c, http.StatusBadRequest, "Invalid room ID", "Cette salle n'existe pas.", err)
+		return nil, false
+	}
+	room, err := models.GetRoomByID(c.Request.Context(), db, id)
+	if err != nil {
+		respondErrorPage(c, http.StatusInternalServerError, "Error querying for room", "La salle n'a pas pu être chargée.", err, slog.Int("room_id", id))
+		return nil, false
+	}
+	if room == nil {
+		respondErrorPage(c, http.StatusNotFound, "Room not found", "Cette salle n'existe pas.", nil, slog.Int("room_id", id))
+		return nil, false
+	}
+	if !canManageRoom(c, room) {
+		respondErrorPage(c, http.StatusForbidden, "Room not managed by user", "Vous ne pouvez modifier que vos propres salles.", nil, slog.Int("room_id", id))
+		return nil, false
+	}
+	return room, true
+}
+
+// roomFormRenderer affiche le formulaire d'une room avec un statut et les erreurs de saisie par champ.
+type roomFormRenderer func(status int, errs map[string]string)
+
+// respondRoomForm affiche à nouveau le formulaire avec les erreurs de saisie sous les champs concernés,
+// ou la page d'erreur pour les autres erreurs.
+func respondRoomForm(c *gin.Context, render roomFormRenderer, message, userMessage string, err error, attrs ...any) {
+	var invalid fieldErrors
+	if !errors.As(err, &invalid) {
+		respondErrorPage(c, roomErrorStatus(err), message, userMessage, err, attrs...)
+		return
+	}
+	status := roomErrorStatus(err)
+	logError(c, status, message, err, attrs...)
+	render(status, invalid.byField())
+}
+
+// adminRoomForm prépare le formulaire d'administration pour créer (room.ID nul) ou modifier une room.
+func adminRoomForm(c *gin.Context, room models.Room) roomFormRenderer {
+	action := "/admin/rooms"
+	if room.ID != 0 {
+		action += "/" + strconv.Itoa(room.ID)
+	}
+	return func(status int, errs map[string]string) {
+		renderHTML(c, status, "room_form.html", gin.H{
+			"csrfToken":  csrfToken(c),
+			"formAction": action,
+			"backURL":    "/admin/rooms",
+			"room":       room,
+			"errors":     errs,
+			"policies":   accessPolicyLabels,
+		})
+	}
+}
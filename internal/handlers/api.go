@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
+	"groom/internal/config"
 	googleapi "groom/internal/google"
 	"groom/internal/models"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,9 +17,9 @@ import (
 // Handler pour lister les rooms en JSON
 func ListRoomsJSONHandler(db *sql.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rooms, err := models.GetAllRooms(db)
+		rooms, err := models.GetAllRooms(c.Request.Context(), db)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Unable to retrieve rooms"})
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve rooms", err)
 			return
 		}
 		c.JSON(http.StatusOK, rooms)
@@ -24,91 +27,167 @@ func ListRoomsJSONHandler(db *sql.DB) gin.HandlerFunc {
 }
 
 // Handler pour créer une room
-func CreateRoomHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
+// La politique d'accès est facultative (room_default_access_policy par défaut).
+func CreateRoomHandler(db *sql.DB, meetService *googleapi.MeetClient, cfgStore *config.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var requestBody struct {
-			Slug string `json:"slug"`
+			Slug         string `json:"slug"`
+			Name         string `json:"name"`
+			Description  string `json:"description"`
+			AccessPolicy string `json:"access_policy"`
 		}
-		if err := c.BindJSON(&requestBody); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err)
 			return
 		}
+		if requestBody.AccessPolicy == "" {
+			requestBody.AccessPolicy = cfgStore.Get().RoomDefaultAccessPolicy
+		}
 
-		existingRoom, err := models.GetRoomBySlug(db, requestBody.Slug)
+		createdRoom, err := createRoom(c, db, meetService, models.Room{
+			Slug:         requestBody.Slug,
+			Name:         requestBody.Name,
+			Description:  requestBody.Description,
+			OwnerEmail:   c.GetString(PrincipalKey),
+			AccessPolicy: requestBody.AccessPolicy,
+		}, roomQuota{}, nil, nil)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verifying room existence"})
+			respondRoomError(c, "Error creating room", err, slog.String("slug", requestBody.Slug))
 			return
 		}
-		if existingRoom != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "A room with the same slug already exists"})
+
+		c.JSON(http.StatusCreated, createdRoom)
+	}
+}
+
+// Handler pour modifier une room
+// name et description sont facultatifs et conservés s'ils sont absents.
+func UpdateRoomHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
 			return
 		}
 
-		space, err := meetService.CreateSpace()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error creating Google Meet space"})
+		// Get and check body params
+		var requestBody struct {
+			Slug        string  `json:"slug"`
+			SpaceID     string  `json:"space_id"`
+			Name        *string `json:"name"`
+			Description *string `json:"description"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("room_id", room.ID))
 			return
 		}
-
-		room := models.Room{
-			Slug:    requestBody.Slug,
-			SpaceID: space.Name,
+		if requestBody.SpaceID == "" {
+			respondError(c, http.StatusBadRequest, "space_id is required", nil, slog.Int("room_id", room.ID))
+			return
 		}
 
-		createdRoom, err := models.CreateRoom(db, room)
+		// Update room
+		_, err := changeRoom(c, db, room.ID, models.AuditRoomUpdate, func(room *models.Room) {
+			room.Slug = requestBody.Slug
+			room.SpaceID = requestBody.SpaceID
+			if requestBody.Name != nil {
+				room.Name = *requestBody.Name
+			}
+			if requestBody.Description != nil {
+				room.Description = *requestBody.Description
+			}
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error inserting room"})
+			respondRoomError(c, "Error updating room", err, slog.Int("room_id", room.ID))
 			return
 		}
 
-		c.JSON(http.StatusCreated, createdRoom)
+		// Return
+		c.JSON(http.StatusOK, gin.H{"message": "Room updated successfully"})
 	}
 }
 
-func UpdateRoomHandler(db *sql.DB) gin.HandlerFunc {
+// POST /api/rooms/:id/rotate-space
+// Remplace l'espace Meet de la room par un nouvel espace : l'ancien lien Meet n'est plus utilisé.
+func RotateRoomSpaceHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get and check query param ID
-		idStr := c.Param("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+		room, ok := managedRoom(c, db)
+		if !ok {
 			return
 		}
 
-		room, err := models.GetRoomByID(db, id)
+		state, err := rotateRoomSpace(c, db, meetService, room)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error querying for room"})
-			return
-		}
-		if room == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			respondRoomError(c, "Error rotating room space", err, slog.Int("room_id", room.ID))
 			return
 		}
+		c.JSON(http.StatusOK, state.Room)
+	}
+}
 
-		// Get and check body params
-		var requestBody struct {
-			Slug    string `json:"slug"`
-			SpaceID string `json:"space_id"`
-		}
-		if err := c.BindJSON(&requestBody); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+// POST /api/rooms/:id/archive et POST /api/rooms/:id/unarchive
+func ArchiveRoomHandler(db *sql.DB, archived bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
 			return
 		}
 
-		// Update room
-		room.Slug = requestBody.Slug
-		room.SpaceID = requestBody.SpaceID
-		room.UpdatedAt = time.Now()
-
-		err = models.UpdateRoom(db, *room)
+		state, err := setRoomArchived(c, db, room.ID, archived)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error updating room"})
+			respondRoomError(c, "Error archiving room", err, slog.Int("room_id", room.ID), slog.Bool("archived", archived))
 			return
 		}
+		c.JSON(http.StatusOK, state.Room)
+	}
+}
 
-		// Return
-		c.JSON(http.StatusOK, gin.H{"message": "Room updated successfully"})
+// rotateRoomSpace crée un nouvel espace Meet et l'attribue à la room.
+func rotateRoomSpace(c *gin.Context, db *sql.DB, meetService *googleapi.MeetClient, room *models.Room) (*models.RoomState, error) {
+	space, err := meetService.CreateSpace(c.Request.Context())
+	if err != nil {
+		return nil, fmt.Errorf("creating Google Meet space: %w", err)
+	}
+	state, err := changeRoom(c, db, room.ID, models.AuditRoomRotateSpace, func(room *models.Room) {
+		room.SpaceID = space.Name
+	})
+	if err != nil {
+		return nil, err
 	}
+	slog.InfoContext(c.Request.Context(), "Room space rotated", slog.String("actor", actor(c)), slog.Int("room_id", room.ID),
+		slog.String("previous_space_id", room.SpaceID), slog.String("space_id", space.Name))
+	return state, nil
+}
+
+// setRoomArchived archive la room (elle n'est plus listée ni accessible) ou la rétablit.
+func setRoomArchived(c *gin.Context, db *sql.DB, id int, archived bool) (*models.RoomState, error) {
+	action := models.AuditRoomUnarchive
+	if archived {
+		action = models.AuditRoomArchive
+	}
+	state, err := changeRoom(c, db, id, action, func(room *models.Room) {
+		switch {
+		case archived && room.ArchivedAt == nil:
+			now := time.Now()
+			room.ArchivedAt = &now
+		case !archived:
+			room.ArchivedAt = nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(c.Request.Context(), "Room archive status changed", slog.String("actor", actor(c)), slog.Int("room_id", id), slog.Bool("archived", archived))
+	return state, nil
+}
+
+// respondRoomError répond à une erreur de createRoom ou changeRoom : les règles de saisie non respectées
+// sont renvoyées telles quelles.
+func respondRoomError(c *gin.Context, message string, err error, attrs ...any) {
+	status := roomErrorStatus(err)
+	if status < http.StatusInternalServerError {
+		message = err.Error()
+	}
+	respondError(c, status, message, err, attrs...)
 }
 
 // Handler pour supprimer une room
@@ -117,14 +196,41 @@ func DeleteRoomHandler(db *sql.DB) gin.HandlerFunc {
 		idStr := c.Param("id")
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID"})
+			respondError(c, http.StatusBadRequest, "Invalid room ID", err, slog.String("room_id", idStr))
+			return
+		}
+
+		room, err := models.GetRoomByID(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error querying for room", err, slog.Int("room_id", id))
+			return
+		}
+		if room == nil {
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.Int("room_id", id))
+			return
+		}
+		if !canManageRoom(c, room) {
+			respondError(c, http.StatusForbidden, "You can only delete your own rooms", nil, slog.Int("room_id", id))
 			return
 		}
 
-		// Supprimer la room de la base de données
-		err = models.DeleteRoom(db, id)
+		// Supprimer la room de la base de données ; son dernier état reste dans le journal d'audit
+		ctx := c.Request.Context()
+		err = models.WithTx(ctx, db, func(tx *sql.Tx) error {
+			before, err := models.LockRoomState(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if before == nil {
+				return errRoomNotFound
+			}
+			if err := models.DeleteRoom(ctx, tx, id); err != nil {
+				return err
+			}
+			return recordAudit(ctx, c, tx, models.AuditRoomDelete, id, before, nil)
+		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error deleting room"})
+			respondError(c, roomErrorStatus(err), "Error deleting room", err, slog.Int("room_id", id))
 			return
 		}
 
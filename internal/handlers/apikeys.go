@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"groom/internal/apikey"
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/keys
+func ListAPIKeysHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		keys, err := models.GetAllAPIKeys(c.Request.Context(), db)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve API keys", err)
+			return
+		}
+		if keys == nil {
+			keys = []models.APIKey{}
+		}
+		c.JSON(http.StatusOK, keys)
+	}
+}
+
+// POST /api/keys
+func CreateAPIKeyHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Name      string     `json:"name" binding:"required"`
+			Owner     string     `json:"owner"`
+			Scopes    []string   `json:"scopes" binding:"required"`
+			ExpiresAt *time.Time `json:"expires_at"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err)
+			return
+		}
+		if err := apikey.ValidateScopes(request.Scopes); err != nil {
+			respondError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+			respondError(c, http.StatusBadRequest, "expires_at must be in the future", nil)
+			return
+		}
+		if request.Owner == "" {
+			request.Owner = actor(c)
+		}
+
+		secret, key, err := apikey.Issue(c.Request.Context(), db, request.Name, request.Owner, request.Scopes, request.ExpiresAt)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error creating API key", err, slog.String("name", request.Name))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "API key issued", slog.String("actor", actor(c)), slog.Int("api_key_id", key.ID), slog.Any("scopes", key.Scopes))
+
+		// Le secret n'est renvoyé qu'à la création
+		c.JSON(http.StatusCreated, gin.H{
+			"api_key": key,
+			"key":     secret,
+		})
+	}
+}
+
+// DELETE /api/keys/:id
+func RevokeAPIKeyHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid API key ID", err)
+			return
+		}
+
+		revoked, err := models.RevokeAPIKey(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error revoking API key", err, slog.Int("api_key_id", id))
+			return
+		}
+		if !revoked {
+			respondError(c, http.StatusNotFound, "API key not found", nil, slog.Int("api_key_id", id))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "API key revoked", slog.String("actor", actor(c)), slog.Int("api_key_id", id))
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+var (
+	errAuditEventNotFound = errors.New("audit event not found for this room")
+	errNothingToRevert    = errors.New("this event has no state to revert to")
+	errRoomNotFound       = errors.New("room not found")
+	errSlugTaken          = errors.New("another room now uses this slug")
+)
+
+// recordAudit enregistre une modification dans le journal d'audit, avec l'auteur, l'adresse IP et l'identifiant
+// de la requête. À appeler dans la transaction de la modification : si l'événement échoue, la modification est annulée.
+func recordAudit(ctx context.Context, c *gin.Context, tx models.DBTX, action string, roomID int, before, after any) error {
+	event, err := newAuditEvent(c, action, roomID, before, after)
+	if err != nil {
+		return err
+	}
+	return models.InsertAuditEvent(ctx, tx, event)
+}
+
+// recordPersonalRoomAudit enregistre une modification de salle personnelle, comme recordAudit mais sans room_id :
+// les salles personnelles ne partagent pas les identifiants des rooms.
+func recordPersonalRoomAudit(ctx context.Context, c *gin.Context, tx models.DBTX, action string, before, after *models.PersonalRoom) error {
+	event, err := newAuditEvent(c, action, 0, before, after)
+	if err != nil {
+		return err
+	}
+	event.RoomID = nil
+	return models.InsertAuditEvent(ctx, tx, event)
+}
+
+func newAuditEvent(c *gin.Context, action string, roomID int, before, after any) (*models.AuditEvent, error) {
+	event := &models.AuditEvent{
+		Actor:     actor(c),
+		Action:    action,
+		RoomID:    &roomID,
+		IP:        c.ClientIP(),
+		RequestID: c.GetString(RequestIDKey),
+	}
+	var err error
+	if event.Before, err = marshalState(before); err != nil {
+		return nil, err
+	}
+	if event.After, err = marshalState(after); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// marshalState renvoie nil pour un état absent (avant une création, après une suppression).
+func marshalState(state any) (json.RawMessage, error) {
+	switch v := state.(type) {
+	case nil:
+		return nil, nil
+	case *models.RoomState:
+		if v == nil {
+			return nil, nil
+		}
+	case *models.GuestLink:
+		if v == nil {
+			return nil, nil
+		}
+	case *models.PersonalRoom:
+		if v == nil {
+			return nil, nil
+		}
+	}
+	return json.Marshal(state)
+}
+
+// GET /api/audit
+// Filtres : actor, action, room_id, since et until (RFC 3339), before_id (pagination) et limit.
+func ListAuditEventsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.AuditFilter{
+			Actor:  c.Query("actor"),
+			Action: c.Query("action"),
+			Limit:  defaultAuditLimit,
+		}
+		var err error
+		if value := c.Query("room_id"); value != "" {
+			if filter.RoomID, err = strconv.Atoi(value); err != nil {
+				respondError(c, http.StatusBadRequest, "room_id must be a number", err)
+				return
+			}
+		}
+		if value := c.Query("before_id"); value != "" {
+			if filter.BeforeID, err = strconv.ParseInt(value, 10, 64); err != nil {
+				respondError(c, http.StatusBadRequest, "before_id must be a number", err)
+				return
+			}
+		}
+		if value := c.Query("limit"); value != "" {
+			if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 || filter.Limit > maxAuditLimit {
+				respondError(c, http.StatusBadRequest, "limit must be a number between 1 and "+strconv.Itoa(maxAuditLimit), err)
+				return
+			}
+		}
+		for _, bound := range []struct {
+			param string
+			value *time.Time
+		}{{"since", &filter.Since}, {"until", &filter.Until}} {
+			if value := c.Query(bound.param); value != "" {
+				if *bound.value, err = time.Parse(time.RFC3339, value); err != nil {
+					respondError(c, http.StatusBadRequest, bound.param+" must be an RFC 3339 date", err)
+					return
+				}
+			}
+		}
+
+		events, err := models.GetAuditEvents(c.Request.Context(), db, filter)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve audit events", err)
+			return
+		}
+		if events == nil {
+			events = []models.AuditEvent{}
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
+
+// GET /api/rooms/:id/history
+func RoomHistoryJSONHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
+			return
+		}
+
+		events, err := models.GetAuditEvents(c.Request.Context(), db, models.AuditFilter{RoomID: room.ID, Limit: maxAuditLimit})
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve room history", err, slog.Int("room_id", room.ID))
+			return
+		}
+		if events == nil {
+			events = []models.AuditEvent{}
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
+
+// POST /api/rooms/:id/revert
+// Rétablit la room dans l'état enregistré après l'événement event_id de son historique.
+func RevertRoomHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
+			return
+		}
+
+		var requestBody struct {
+			EventID int64 `json:"event_id" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("room_id", room.ID))
+			return
+		}
+
+		state, err := revertRoom(c, db, room.ID, requestBody.EventID)
+		if err != nil {
+			status, message := revertErrorStatus(err), "Unable to revert room"
+			if status < http.StatusInternalServerError {
+				message = err.Error()
+			}
+			respondError(c, status, message, err, slog.Int("room_id", room.ID), slog.Int64("event_id", requestBody.EventID))
+			return
+		}
+		c.JSON(http.StatusOK, state)
+	}
+}
+
+// GET /admin/rooms/:id/history
+func RoomHistoryHTMLHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid room ID", "Cette salle n'existe pas.", err)
+			return
+		}
+		room, err := models.GetRoomByID(c.Request.Context(), db, id)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Error querying for room", "L'historique de la salle n'a pas pu être chargé.", err, slog.Int("room_id", id))
+			return
+		}
+		events, err := models.GetAuditEvents(c.Request.Context(), db, models.AuditFilter{RoomID: id, Limit: maxAuditLimit})
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve room history", "L'historique de la salle n'a pas pu être chargé.", err, slog.Int("room_id", id))
+			return
+		}
+
+		type EventView struct {
+			models.AuditEvent
+			Before, After string
+			Revertible    bool
+		}
+		views := make([]EventView, 0, len(events))
+		for _, event := range events {
+			views = append(views, EventView{
+				AuditEvent: event,
+				Before:     string(event.Before),
+				After:      string(event.After),
+				Revertible: room != nil && len(event.After) > 0 && !strings.HasPrefix(event.Action, "guest_link."),
+			})
+		}
+
+		renderHTML(c, http.StatusOK, "room_history.html", gin.H{
+			"csrfToken": csrfToken(c),
+			"roomID":    id,
+			"room":      room,
+			"events":    views,
+		})
+	}
+}
+
+// POST /admin/rooms/:id/revert
+func RevertRoomFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid room ID", "Cette salle n'existe pas.", err)
+			return
+		}
+		eventID, err := strconv.ParseInt(c.PostForm("event_id"), 10, 64)
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid audit event ID", "Aucune version n'a été indiquée.", err, slog.Int("room_id", id))
+			return
+		}
+
+		if _, err := revertRoom(c, db, id, eventID); err != nil {
+			respondErrorPage(c, revertErrorStatus(err), "Unable to revert room", revertErrorMessage(err), err, slog.Int("room_id", id), slog.Int64("event_id", eventID))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/rooms/"+strconv.Itoa(id)+"/history")
+	}
+}
+
+// revertRoom rétablit la room (nom, espace Meet, métadonnées, archivage et autorisations) telle qu'enregistrée
+// après l'événement, et journalise ce retour en arrière dans la même transaction.
+func revertRoom(c *gin.Context, db *sql.DB, roomID int, eventID int64) (*models.RoomState, error) {
+	ctx := c.Request.Context()
+	var after *models.RoomState
+
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		event, err := models.GetAuditEventByID(ctx, tx, eventID)
+		if err != nil {
+			return err
+		}
+		if event == nil || event.RoomID == nil || *event.RoomID != roomID {
+			return errAuditEventNotFound
+		}
+		var target models.RoomState
+		if len(event.After) == 0 || json.Unmarshal(event.After, &target) != nil || target.Slug == "" {
+			return errNothingToRevert
+		}
+
+		before, err := models.LockRoomState(ctx, tx, roomID)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errRoomNotFound
+		}
+		if existing, err := models.GetRoomBySlug(ctx, tx, target.Slug); err != nil {
+			return err
+		} else if existing != nil && existing.ID != roomID {
+			return errSlugTaken
+		}
+
+		room := before.Room
+		room.Slug, room.SpaceID = target.Slug, target.SpaceID
+		room.Name, room.Description, room.ArchivedAt = target.Name, target.Description, target.ArchivedAt
+		if err := models.UpdateRoom(ctx, tx, room); err != nil {
+			if errors.Is(err, models.ErrSlugTaken) {
+				return errSlugTaken
+			}
+			return err
+		}
+		if err := models.SetRoomAccess(ctx, tx, roomID, target.AccessPolicy, target.Access); err != nil {
+			return err
+		}
+		if after, err = models.GetRoomState(ctx, tx, roomID); err != nil {
+			return err
+		}
+
+		revert, err := newAuditEvent(c, models.AuditRoomRevert, roomID, before, after)
+		if err != nil {
+			return err
+		}
+		revert.RevertedEventID = &eventID
+		return models.InsertAuditEvent(ctx, tx, revert)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Room reverted", slog.String("actor", actor(c)), slog.Int("room_id", roomID), slog.Int64("event_id", eventID))
+	return after, nil
+}
+
+func revertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errAuditEventNotFound), errors.Is(err, errRoomNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errNothingToRevert):
+		return http.StatusBadRequest
+	case errors.Is(err, errSlugTaken):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func revertErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errAuditEventNotFound), errors.Is(err, errRoomNotFound):
+		return "Cette version de la salle est introuvable."
+	case errors.Is(err, errNothingToRevert):
+		return "Cette version ne peut pas être restaurée."
+	case errors.Is(err, errSlugTaken):
+		return "Une autre salle utilise désormais ce nom."
+	}
+	return "La salle n'a pas pu être restaurée."
+}
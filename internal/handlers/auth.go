@@ -1,18 +1,28 @@
 package handlers
 
 import (
-	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json" // Utilisé pour la désérialisation du token JSON
 	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
+	"groom/internal/apikey"
+	"groom/internal/config"
 	googleapi "groom/internal/google"
+	"groom/internal/jwtauth"
+	"groom/internal/mtls"
+	sessionstore "groom/internal/session"
 
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
-	oauth2api "google.golang.org/api/oauth2/v2" // Renommé pour éviter le conflit
-	"google.golang.org/api/option"
 )
 
 // Erreur personnalisée pour l'utilisateur non authentifié
@@ -20,129 +30,318 @@ var ErrUserNotAuthenticated = errors.New("user not authenticated")
 
 const GoogleClientKey = "googleClient"
 
+const lastSeenKey = "last_seen"
+
+// Clés de session de la tentative de connexion en cours, supprimées au callback
+const (
+	oauthStateKey    = "oauth_state"
+	oauthNonceKey    = "oauth_nonce"
+	oauthVerifierKey = "oauth_verifier"
+)
+
 // Middleware pour vérifier l'authentification Google
 func RequireLogin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		user := session.Get("user")
+		if authenticate(c) {
+			c.Next()
+		}
+	}
+}
 
-		if user == nil {
-			// Enregistrer l'URL d'origine dans la session avant de rediriger vers Google OAuth
-			session.Set("redirect", c.Request.RequestURI)
-			session.Save()
+// authenticate vérifie la session de l'utilisateur et expose son identité et son client Google dans le contexte.
+// Sinon, elle répond (redirection vers la connexion ou page d'erreur) et renvoie false.
+func authenticate(c *gin.Context) bool {
+	session := sessions.Default(c)
 
-			// Rediriger vers la page de connexion OAuth
-			c.Redirect(http.StatusFound, "/auth/login")
-			c.Abort()
-			return
-		}
+	// La session a été révoquée (par un administrateur ou par "se déconnecter partout")
+	if revoked, _ := session.Get(sessionstore.RevokedKey).(bool); revoked {
+		session.Delete(sessionstore.RevokedKey)
+		session.Save()
+		respondErrorPage(c, http.StatusUnauthorized, "Session revoked", "Votre session a été fermée. Veuillez vous reconnecter.", nil)
+		return false
+	}
 
-		// Récupérer le token OAuth2 depuis la session
-		tokenJSON := session.Get("token")
-		if tokenJSON == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
-			return
+	user := session.Get("user")
+	if user == nil {
+		// Enregistrer l'URL d'origine dans la session avant de rediriger vers Google OAuth
+		if c.Request.Method == http.MethodGet {
+			session.Set("redirect", c.Request.URL.RequestURI())
+			session.Save()
 		}
 
-		// Désérialiser le token JSON en objet oauth2.Token
-		var oauthToken oauth2.Token
-		err := json.Unmarshal(tokenJSON.([]byte), &oauthToken)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deserialize OAuth 2 token", "details": err.Error()})
-			return
+		// Rediriger vers la page de connexion OAuth
+		c.Redirect(http.StatusFound, "/auth/login")
+		c.Abort()
+		return false
+	}
+
+	// Récupérer le token OAuth2 depuis la session
+	tokenJSON, ok := session.Get("token").([]byte)
+	if !ok {
+		respondErrorPage(c, http.StatusUnauthorized, "User not authenticated", "Votre session n'est plus valide. Veuillez vous reconnecter.", nil)
+		return false
+	}
+
+	// Désérialiser le token JSON en objet oauth2.Token
+	var oauthToken oauth2.Token
+	err := json.Unmarshal(tokenJSON, &oauthToken)
+	if err != nil {
+		respondErrorPage(c, http.StatusInternalServerError, "Failed to deserialize OAuth 2 token", "Votre session n'a pas pu être lue. Veuillez vous reconnecter.", err)
+		return false
+	}
+
+	client := googleapi.UserOAuth().Client(c.Request.Context(), &oauthToken)
+
+	// Expiration glissante : la session est réenregistrée (et son cookie prolongé) au plus une fois par minute
+	if lastSeen, _ := session.Get(lastSeenKey).(int64); time.Since(time.Unix(lastSeen, 0)) > time.Minute {
+		session.Set(lastSeenKey, time.Now().Unix())
+		if err := session.Save(); err != nil {
+			logError(c, http.StatusInternalServerError, "Failed to extend session", err)
 		}
+	}
 
-		client := googleapi.UserOAuthConfig.Client(context.Background(), &oauthToken)
+	c.Set(ActorKey, user)
+	c.Set(GoogleClientKey, client)
 
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve Google API client", "details": err.Error()})
+	return true
+}
+
+// Redirige vers Google OAuth. Le state (anti-CSRF), le nonce OpenID Connect et le code verifier PKCE
+// sont tirés au hasard à chaque tentative et conservés dans la session jusqu'au callback.
+func LoginHandler(cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := randomToken()
+		nonce := randomToken()
+		verifier := oauth2.GenerateVerifier()
+
+		session := sessions.Default(c)
+		session.Set(oauthStateKey, state)
+		session.Set(oauthNonceKey, nonce)
+		session.Set(oauthVerifierKey, verifier)
+		if err := session.Save(); err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Failed to save login state", "La connexion n'a pas pu démarrer. Veuillez réessayer.", err)
 			return
 		}
 
-		c.Set(GoogleClientKey, client)
+		options := []oauth2.AuthCodeOption{
+			oauth2.AccessTypeOffline,
+			oauth2.S256ChallengeOption(verifier),
+			oauth2.SetAuthURLParam("nonce", nonce),
+		}
+		if hd := signInHostedDomain(cfgStore.Get()); hd != "" {
+			options = append(options, oauth2.SetAuthURLParam("hd", hd))
+		}
+		// Depuis la page "accès refusé", l'utilisateur peut choisir un autre compte Google
+		if c.Query("prompt") == "select_account" {
+			options = append(options, oauth2.SetAuthURLParam("prompt", "select_account"))
+		}
 
-		c.Next()
+		url := googleapi.UserOAuth().AuthCodeURL(state, options...)
+		c.Redirect(http.StatusFound, url)
 	}
 }
 
-// Redirige vers Google OAuth
-func LoginHandler(c *gin.Context) {
-	url := googleapi.UserOAuthConfig.AuthCodeURL("state", oauth2.AccessTypeOffline)
-	c.Redirect(http.StatusFound, url)
-}
-
 // Callback après authentification Google
-func AuthCallbackHandler(googleWorkspaceDomain string) gin.HandlerFunc {
+func AuthCallbackHandler(cfgStore *config.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		code := c.Query("code")
-		token, err := googleapi.UserOAuthConfig.Exchange(context.Background(), code)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token"})
+		oauthConfig := googleapi.UserOAuth()
+
+		// Le state, le nonce et le verifier ne servent qu'une fois
+		session := sessions.Default(c)
+		state, _ := session.Get(oauthStateKey).(string)
+		nonce, _ := session.Get(oauthNonceKey).(string)
+		verifier, _ := session.Get(oauthVerifierKey).(string)
+		session.Delete(oauthStateKey)
+		session.Delete(oauthNonceKey)
+		session.Delete(oauthVerifierKey)
+		if err := session.Save(); err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Failed to clear login state", "La connexion n'a pas pu aboutir. Veuillez réessayer.", err)
 			return
 		}
 
-		// Créer un client avec le token OAuth
-		client := googleapi.UserOAuthConfig.Client(context.Background(), token)
+		if oauthError := c.Query("error"); oauthError != "" {
+			respondErrorPage(c, http.StatusUnauthorized, "Google sign-in failed", "La connexion a été annulée ou refusée par Google.", nil, slog.String("oauth_error", oauthError))
+			return
+		}
+
+		if state == "" || subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(state)) != 1 {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid OAuth state", "Cette demande de connexion a expiré ou n'a pas été initiée par ce navigateur. Veuillez réessayer.", nil)
+			return
+		}
 
-		// Utiliser oauth2api.NewService pour initialiser le service OAuth2
-		oauth2Service, err := oauth2api.NewService(context.Background(), option.WithHTTPClient(client))
+		token, err := oauthConfig.Exchange(c.Request.Context(), c.Query("code"), oauth2.VerifierOption(verifier))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create OAuth2 service"})
+			respondErrorPage(c, http.StatusBadGateway, "Failed to exchange token", "Google n'a pas validé la connexion. Veuillez réessayer.", err)
 			return
 		}
 
-		// Récupérer les informations utilisateur via l'API Google OAuth2
-		userinfo, err := oauth2Service.Userinfo.Get().Do()
+		rawIDToken, _ := token.Extra("id_token").(string)
+		if rawIDToken == "" {
+			respondErrorPage(c, http.StatusBadGateway, "Missing ID token", "Google n'a pas fourni d'identité. Veuillez réessayer.", nil)
+			return
+		}
+
+		identity, err := googleapi.VerifyIDToken(c.Request.Context(), rawIDToken, oauthConfig.ClientID, nonce)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user info"})
+			respondErrorPage(c, http.StatusUnauthorized, "Invalid ID token", "Votre identité n'a pas pu être vérifiée. Veuillez réessayer.", err)
 			return
 		}
 
-		// Vérifier que l'utilisateur est du domaine inclusion.gouv.fr
-		if userinfo.Hd != googleWorkspaceDomain {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized domain"})
+		// Règles d'accès : domaines, listes d'autorisation et de refus, email vérifié
+		if rejection := checkSignIn(cfgStore.Get(), identity); rejection != nil {
+			logError(c, http.StatusForbidden, "Sign-in rejected", nil,
+				slog.String("email", identity.Email), slog.String("hd", identity.Domain),
+				slog.Bool("email_verified", identity.EmailVerified), slog.String("reason", rejection.reason))
+			renderHTML(c, http.StatusForbidden, "access_denied.html", gin.H{
+				"Email":     identity.Email,
+				"Message":   rejection.message,
+				"RequestID": c.GetString(RequestIDKey),
+			})
+			c.Abort()
 			return
 		}
 
 		// Sérialiser le token en JSON pour le stocker dans la session
 		tokenJSON, err := json.Marshal(token)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serialize token"})
+			respondErrorPage(c, http.StatusInternalServerError, "Failed to serialize token", "La connexion n'a pas pu aboutir. Veuillez réessayer.", err, slog.String("email", identity.Email))
 			return
 		}
 
-		// Stocker l'utilisateur et le token sérialisé dans la session
-		session := sessions.Default(c)
-		session.Set("user", userinfo.Email)
-		session.Set("token", tokenJSON) // Stocker le token OAuth2 au format JSON
-		session.Save()
+		// Rediriger l'utilisateur vers l'URL qu'il voulait initialement accéder, si elle est locale
+		redirect, _ := session.Get("redirect").(string)
+		session.Delete("redirect")
 
-		// Rediriger l'utilisateur vers l'URL qu'il voulait initialement accéder
-		redirect := session.Get("redirect")
-		if redirect != nil {
-			session.Delete("redirect")
-			session.Save()
-			c.Redirect(http.StatusFound, redirect.(string))
-		} else {
-			c.Redirect(http.StatusFound, "/")
+		// Stocker l'utilisateur et le token sérialisé dans une session au nouveau jeton
+		sessionstore.Regenerate(session)
+		session.Set("user", identity.Email)
+		session.Set("token", tokenJSON) // Stocker le token OAuth2 au format JSON
+		if err := session.Save(); err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Failed to save session", "La connexion n'a pas pu aboutir. Veuillez réessayer.", err, slog.String("email", identity.Email))
+			return
 		}
+
+		c.Redirect(http.StatusFound, safeRedirect(redirect))
 	}
 }
 
-// Déconnexion
-func LogoutHandler(c *gin.Context) {
-	session := sessions.Default(c)
-	session.Clear()
-	session.Save()
-	c.Redirect(http.StatusFound, "/")
+// safeRedirect n'accepte que les chemins locaux : ni schéma ni hôte, ni "//" ou "/\" que les navigateurs
+// interprètent comme une URL absolue. Toute autre cible renvoie vers l'accueil.
+func safeRedirect(target string) string {
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.ContainsAny(target, "\\\r\n\t") {
+		return "/"
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "/"
+	}
+	return target
+}
+
+// randomToken renvoie 32 octets aléatoires encodés en base64 URL.
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Déconnexion : la session est supprimée côté serveur et le cookie expiré
+func LogoutHandler(cookieOptions sessions.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		session.Clear()
+		cookieOptions.MaxAge = -1
+		session.Options(cookieOptions)
+		if err := session.Save(); err != nil {
+			logError(c, http.StatusInternalServerError, "Failed to delete session", err)
+		}
+		c.Redirect(http.StatusFound, "/")
+	}
 }
 
-func ApiKeyMiddleware(apiKey string) gin.HandlerFunc {
+// Middleware d'authentification des clients de l'API par l'en-tête X-API-KEY : clé émise en base
+// ou, à défaut, clé partagée api_key (tous les scopes)
+func ApiKeyMiddleware(db *sql.DB, cfgStore *config.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestApiKey := c.GetHeader("X-API-KEY")
-		if requestApiKey != apiKey {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+		if requestApiKey == "" {
+			respondError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			return
+		}
+
+		sharedApiKey := cfgStore.Get().APIKey
+		if sharedApiKey != "" && subtle.ConstantTimeCompare([]byte(requestApiKey), []byte(sharedApiKey)) == 1 {
+			c.Set(ActorKey, "api-key")
+			c.Set(ScopesKey, apikey.Scopes)
+			c.Next()
+			return
+		}
+
+		key, err := apikey.Authenticate(c.Request.Context(), db, requestApiKey)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to check API key", err)
+			return
+		}
+		if key == nil {
+			respondError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			return
+		}
+
+		c.Set(ActorKey, "api-key:"+key.Name)
+		c.Set(ScopesKey, key.Scopes)
+		c.Set(PrincipalKey, key.Owner)
+		c.Next()
+	}
+}
+
+// Middleware d'authentification de l'API : certificat client vérifié et associé à une identité (clientCerts non nil),
+// token JWT "Authorization: Bearer" des services si un JWKS est configuré (verifier non nil), clé X-API-KEY sinon
+func APIAuthMiddleware(db *sql.DB, cfgStore *config.Store, verifier *jwtauth.Verifier, clientCerts mtls.Mapping) gin.HandlerFunc {
+	apiKeyAuth := ApiKeyMiddleware(db, cfgStore)
+	return func(c *gin.Context) {
+		// Certificat client déjà vérifié par la poignée de main TLS ; sans identité connue, les autres méthodes s'appliquent
+		if tlsState := c.Request.TLS; clientCerts != nil && tlsState != nil && len(tlsState.VerifiedChains) > 0 {
+			if identity, scopes, ok := clientCerts.Identify(tlsState.VerifiedChains[0][0]); ok {
+				c.Set(ActorKey, "mtls:"+identity)
+				c.Set(ScopesKey, scopes)
+				c.Set(PrincipalKey, identity)
+				c.Next()
+				return
+			}
+		}
+
+		scheme, rawToken, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") {
+			apiKeyAuth(c)
+			return
+		}
+
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		if verifier == nil {
+			respondError(c, http.StatusUnauthorized, "Bearer tokens are not accepted", nil)
+			return
+		}
+		identity, err := verifier.Verify(c.Request.Context(), strings.TrimSpace(rawToken))
+		if err != nil {
+			respondError(c, http.StatusUnauthorized, "Invalid bearer token", err)
+			return
+		}
+		c.Writer.Header().Del("WWW-Authenticate")
+
+		c.Set(ActorKey, "jwt:"+identity.Principal())
+		c.Set(ScopesKey, identity.Scopes)
+		c.Set(PrincipalKey, identity.Principal())
+		c.Next()
+	}
+}
+
+// Middleware vérifiant que le client de l'API dispose du scope demandé
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !apikey.HasScope(c.GetStringSlice(ScopesKey), scope) {
+			respondError(c, http.StatusForbidden, "Missing scope "+scope, nil)
 			return
 		}
 		c.Next()
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// actor renvoie l'auteur de la requête : utilisateur connecté, client API ou anonyme.
+func actor(c *gin.Context) string {
+	if actor := c.GetString(ActorKey); actor != "" {
+		return actor
+	}
+	if _, exists := c.Get(sessions.DefaultKey); exists {
+		if user, ok := sessions.Default(c).Get("user").(string); ok {
+			return user
+		}
+	}
+	return "anonymous"
+}
+
+// logError journalise la cause d'une erreur avec la route et l'auteur de la requête.
+func logError(c *gin.Context, status int, message string, err error, attrs ...any) {
+	attrs = append(attrs,
+		slog.String("route", c.FullPath()),
+		slog.String("actor", actor(c)),
+		slog.Int("status", status),
+	)
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	level := slog.LevelWarn
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	slog.Log(c.Request.Context(), level, message, attrs...)
+}
+
+// Statut non standard (nginx) utilisé dans les logs quand le client a abandonné la requête
+const statusClientClosedRequest = 499
+
+// errorStatus ajuste le statut d'une erreur d'exécution : un délai dépassé (Postgres, Meet) donne un 503,
+// une requête abandonnée par le client n'appelle pas de réponse.
+func errorStatus(c *gin.Context, status int, err error) int {
+	switch {
+	case err == nil || status < http.StatusInternalServerError:
+		return status
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, context.Canceled) && c.Request.Context().Err() != nil:
+		return statusClientClosedRequest
+	}
+	return status
+}
+
+// respondError journalise l'erreur et renvoie une réponse JSON contenant l'identifiant de requête.
+func respondError(c *gin.Context, status int, message string, err error, attrs ...any) {
+	status = errorStatus(c, status, err)
+	logError(c, status, message, err, attrs...)
+	if status == statusClientClosedRequest {
+		c.AbortWithStatus(status)
+		return
+	}
+	if status == http.StatusServiceUnavailable {
+		message = "Service temporarily unavailable"
+	}
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":      message,
+		"request_id": c.GetString(RequestIDKey),
+	})
+}
+
+// respondErrorText fait de même pour les pages HTML, en texte brut.
+func respondErrorText(c *gin.Context, status int, message string, err error, attrs ...any) {
+	status = errorStatus(c, status, err)
+	logError(c, status, message, err, attrs...)
+	if status == statusClientClosedRequest {
+		c.AbortWithStatus(status)
+		return
+	}
+	if status == http.StatusServiceUnavailable {
+		message = "Service temporarily unavailable"
+	}
+	c.String(status, "%s (request ID: %s)", message, c.GetString(RequestIDKey))
+	c.Abort()
+}
+
+// respondErrorPage journalise l'erreur et affiche la page d'erreur HTML avec un message destiné à l'utilisateur.
+func respondErrorPage(c *gin.Context, status int, message, userMessage string, err error, attrs ...any) {
+	status = errorStatus(c, status, err)
+	logError(c, status, message, err, attrs...)
+	if status == statusClientClosedRequest {
+		c.AbortWithStatus(status)
+		return
+	}
+	if status == http.StatusServiceUnavailable {
+		userMessage = "Le service est momentanément indisponible. Veuillez réessayer dans quelques instants."
+	}
+	renderHTML(c, status, "error.html", gin.H{
+		"Title":     errorPageTitle(status),
+		"Message":   userMessage,
+		"RequestID": c.GetString(RequestIDKey),
+	})
+	c.Abort()
+}
+
+func errorPageTitle(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "Connexion impossible"
+	case status == http.StatusForbidden:
+		return "Accès refusé"
+	case status == http.StatusTooManyRequests:
+		return "Trop de requêtes"
+	case status == http.StatusNotFound, status == http.StatusGone:
+		return "Salle introuvable"
+	case status < http.StatusInternalServerError:
+		return "Requête invalide"
+	}
+	return "Erreur interne"
+}
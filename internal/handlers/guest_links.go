@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"groom/internal/config"
+	"groom/internal/guestlink"
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/rooms/:id/guest-links
+func ListGuestLinksHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
+			return
+		}
+
+		links, err := models.GetRoomGuestLinks(c.Request.Context(), db, room.ID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve guest links", err, slog.Int("room_id", room.ID))
+			return
+		}
+		if links == nil {
+			links = []models.GuestLink{}
+		}
+		c.JSON(http.StatusOK, links)
+	}
+}
+
+// POST /api/rooms/:id/guest-links
+// Le lien signé n'est renvoyé qu'à la création.
+func CreateGuestLinkHandler(db *sql.DB, signer *guestlink.Signer, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		room, ok := managedRoom(c, db)
+		if !ok {
+			return
+		}
+
+		var request struct {
+			Label     string    `json:"label"`
+			ExpiresAt time.Time `json:"expires_at" binding:"required"`
+			MaxUses   *int      `json:"max_uses"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("room_id", room.ID))
+			return
+		}
+		cfg := cfgStore.Get()
+		if !request.ExpiresAt.After(time.Now()) {
+			respondError(c, http.StatusBadRequest, "expires_at must be in the future", nil, slog.Int("room_id", room.ID))
+			return
+		}
+		if request.ExpiresAt.After(time.Now().Add(cfg.GuestLinkMaxTTL)) {
+			respondError(c, http.StatusBadRequest, "expires_at is too far in the future (guest_link_max_ttl is "+cfg.GuestLinkMaxTTL.String()+")", nil, slog.Int("room_id", room.ID))
+			return
+		}
+		if request.MaxUses != nil && *request.MaxUses < 1 {
+			respondError(c, http.StatusBadRequest, "max_uses must be at least 1", nil, slog.Int("room_id", room.ID))
+			return
+		}
+
+		link := models.GuestLink{
+			RoomID:    room.ID,
+			Label:     request.Label,
+			CreatedBy: actor(c),
+			ExpiresAt: request.ExpiresAt,
+			MaxUses:   request.MaxUses,
+		}
+		ctx := c.Request.Context()
+		err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+			if err := models.CreateGuestLink(ctx, tx, &link); err != nil {
+				return err
+			}
+			return recordAudit(ctx, c, tx, models.AuditGuestLinkCreate, room.ID, nil, &link)
+		})
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error creating guest link", err, slog.Int("room_id", room.ID))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Guest link issued", slog.String("actor", actor(c)), slog.Int("guest_link_id", link.ID),
+			slog.Int("room_id", room.ID), slog.Time("expires_at", link.ExpiresAt))
+
+		c.JSON(http.StatusCreated, gin.H{
+			"guest_link": link,
+			"url":        guestURL(cfg.PublicURL, room.Slug, signer.Sign(link.ID, room.ID, link.ExpiresAt)),
+		})
+	}
+}
+
+// DELETE /api/guest-links/:id
+func RevokeGuestLinkHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid guest link ID", err, slog.String("guest_link_id", idStr))
+			return
+		}
+
+		link, err := models.GetGuestLinkByID(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error querying for guest link", err, slog.Int("guest_link_id", id))
+			return
+		}
+		if link == nil {
+			respondError(c, http.StatusNotFound, "Guest link not found", nil, slog.Int("guest_link_id", id))
+			return
+		}
+		room, err := models.GetRoomByID(c.Request.Context(), db, link.RoomID)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error querying for room", err, slog.Int("room_id", link.RoomID))
+			return
+		}
+		if room != nil && !canManageRoom(c, room) {
+			respondError(c, http.StatusForbidden, "You can only revoke guest links of your own rooms", nil, slog.Int("guest_link_id", id))
+			return
+		}
+
+		var revoked bool
+		ctx := c.Request.Context()
+		err = models.WithTx(ctx, db, func(tx *sql.Tx) error {
+			if revoked, err = models.RevokeGuestLink(ctx, tx, id); err != nil || !revoked {
+				return err
+			}
+			after := *link
+			now := time.Now()
+			after.RevokedAt = &now
+			return recordAudit(ctx, c, tx, models.AuditGuestLinkRevoke, link.RoomID, link, &after)
+		})
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error revoking guest link", err, slog.Int("guest_link_id", id))
+			return
+		}
+		if !revoked {
+			respondError(c, http.StatusNotFound, "Guest link not found or already revoked", nil, slog.Int("guest_link_id", id))
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Guest link revoked", slog.String("actor", actor(c)), slog.Int("guest_link_id", id))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// managedRoom charge la room :id et vérifie que la requête peut la gérer ; sinon elle répond et renvoie false.
+func managedRoom(c *gin.Context, db *sql.DB) (*models.Room, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid room ID", err, slog.String("room_id", idStr))
+		return nil, false
+	}
+
+	room, err := models.GetRoomByID(c.Request.Context(), db, id)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Error querying for room", err, slog.Int("room_id", id))
+		return nil, false
+	}
+	if room == nil {
+		respondError(c, http.StatusNotFound, "Room not found", nil, slog.Int("room_id", id))
+		return nil, false
+	}
+	if !canManageRoom(c, room) {
+		respondError(c, http.StatusForbidden, "You can only manage your own rooms", nil, slog.Int("room_id", id))
+		return nil, false
+	}
+	return room, true
+}
+
+// guestURL construit le lien à transmettre à l'invité, absolu si public_url est configurée.
+func guestURL(publicURL, slug, token string) string {
+	return strings.TrimRight(publicURL, "/") + "/" + url.PathEscape(slug) + "?" + url.Values{guestlink.QueryParam: {token}}.Encode()
+}
+
+// Message affiché à l'invité dont le lien est refusé
+const guestLinkRejected = "Ce lien invité n'est pas valide, a expiré ou a déjà été utilisé. Demandez un nouveau lien à la personne qui vous a invité."
+
+// checkGuestLink vérifie le lien invité présenté pour la room, sans consommer d'utilisation, et renvoie son identifiant.
+// Elle répond elle-même et renvoie false si le lien n'est pas utilisable.
+func checkGuestLink(c *gin.Context, db *sql.DB, signer *guestlink.Signer, room *models.Room, token string) (int, bool) {
+	id, err := signer.Verify(token, room.ID)
+	if err != nil {
+		respondErrorPage(c, http.StatusForbidden, "Guest link rejected", guestLinkRejected, nil,
+			slog.Int("room_id", room.ID), slog.Int("guest_link_id", id), slog.String("reason", err.Error()))
+		return 0, false
+	}
+
+	link, err := models.GetGuestLinkByID(c.Request.Context(), db, id)
+	if err != nil {
+		respondErrorPage(c, http.StatusInternalServerError, "Unable to check guest link", "Votre lien invité n'a pas pu être vérifié. Veuillez réessayer.", err, slog.Int("guest_link_id", id))
+		return 0, false
+	}
+	if link == nil || !link.Usable(room.ID, time.Now()) {
+		respondErrorPage(c, http.StatusForbidden, "Guest link rejected", guestLinkRejected, nil,
+			slog.Int("room_id", room.ID), slog.Int("guest_link_id", id), slog.String("reason", "revoked, expired or used up"))
+		return 0, false
+	}
+	return id, true
+}
+
+// useGuestLink consomme une utilisation du lien, une fois l'espace Meet résolu, et la journalise dans audit_events
+// dans la même transaction. Elle répond elle-même et renvoie false si le lien a été épuisé ou révoqué entre-temps.
+func useGuestLink(c *gin.Context, db *sql.DB, room *models.Room, id int) bool {
+	ctx := c.Request.Context()
+	var link *models.GuestLink
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if link, err = models.UseGuestLink(ctx, tx, id, room.ID); err != nil || link == nil {
+			return err
+		}
+		event, err := newAuditEvent(c, models.AuditGuestLinkUse, room.ID, nil, link)
+		if err != nil {
+			return err
+		}
+		event.Actor = "guest-link:" + strconv.Itoa(id)
+		return models.InsertAuditEvent(ctx, tx, event)
+	})
+	if err != nil {
+		respondErrorPage(c, http.StatusInternalServerError, "Unable to use guest link", "Votre lien invité n'a pas pu être vérifié. Veuillez réessayer.", err, slog.Int("guest_link_id", id))
+		return false
+	}
+	if link == nil {
+		respondErrorPage(c, http.StatusForbidden, "Guest link rejected", guestLinkRejected, nil,
+			slog.Int("room_id", room.ID), slog.Int("guest_link_id", id), slog.String("reason", "revoked, expired or used up"))
+		return false
+	}
+
+	slog.InfoContext(ctx, "Guest link used", slog.Int("guest_link_id", link.ID), slog.Int("room_id", room.ID),
+		slog.Int("uses", link.Uses), slog.String("ip", c.ClientIP()), slog.String("user_agent", c.Request.UserAgent()))
+	return true
+}
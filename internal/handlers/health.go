@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"groom/internal/health"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /livez
+func LivezHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// GET /readyz
+func ReadyzHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Seules les dépendances critiques conditionnent la disponibilité : Meet en panne n'empêche pas de servir
+		if !checker.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	}
+}
+
+// GET /healthz[?verbose]
+func HealthzHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := checker.Status()
+
+		httpStatus := http.StatusOK
+		if status == health.StatusUnhealthy {
+			httpStatus = http.StatusServiceUnavailable
+		}
+
+		response := gin.H{"status": status}
+		if _, verbose := c.GetQuery("verbose"); verbose {
+			response["checks"] = checker.Results()
+		}
+		c.JSON(httpStatus, response)
+	}
+}
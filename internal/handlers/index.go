@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"database/sql"
+	"groom/internal/config"
 	googleapi "groom/internal/google"
+	"groom/internal/guestlink"
 	"groom/internal/models"
+	"groom/internal/rbac"
+	"log/slog"
 	"net/http"
 
+	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,23 +34,34 @@ func getRoomParticipantCount(spaceID string, activeConferences []*googleapi.Conf
 }
 
 // GET /
-func ListRoomsHTMLHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
+func ListRoomsHTMLHandler(db *sql.DB, meetService *googleapi.MeetClient, resolver *rbac.Resolver, cfgStore *config.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		rooms, err := models.GetAllRooms(db)
+		rooms, err := models.GetAllRooms(c.Request.Context(), db)
 		if err != nil {
-			c.String(http.StatusInternalServerError, "Unable to retrieve rooms")
+			respondErrorText(c, http.StatusInternalServerError, "Unable to retrieve rooms", err)
 			return
 		}
 
-		activeConferences, err := meetService.ListActiveConferences()
+		// Ne montrer que les rooms auxquelles l'utilisateur a accès
+		access, err := models.GetAllRoomAccess(c.Request.Context(), db)
 		if err != nil {
-			c.String(http.StatusInternalServerError, "Unable to retrieve active conferences")
+			respondErrorText(c, http.StatusInternalServerError, "Unable to retrieve room access", err)
+			return
+		}
+		email := c.GetString(ActorKey)
+		role, _ := c.Get(RoleKey)
+		current, _ := role.(rbac.Role)
+
+		activeConferences, err := meetService.ListActiveConferences(c.Request.Context())
+		if err != nil {
+			respondErrorText(c, http.StatusInternalServerError, "Unable to retrieve active conferences", err)
 			return
 		}
 
 		type RoomView struct {
 			ID               int    `json:"id"`
 			Slug             string `json:"slug"`
+			Name             string `json:"name"`
 			SpaceID          string `json:"space_id"`
 			IsOccupied       bool   `json:"is_occupied"`
 			ParticipantCount int    `json:"participant_count"`
@@ -53,9 +69,22 @@ func ListRoomsHTMLHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.Han
 
 		var roomViews []RoomView
 		for _, room := range rooms {
+			if room.ArchivedAt != nil {
+				continue
+			}
+			allowed, err := resolver.CanAccessRoom(c.Request.Context(), email, current, room, access[room.ID])
+			if err != nil {
+				respondErrorText(c, http.StatusServiceUnavailable, "Unable to check room access", err, slog.Int("room_id", room.ID))
+				return
+			}
+			if !allowed {
+				continue
+			}
+
 			roomView := RoomView{
 				ID:               room.ID,
 				Slug:             room.Slug,
+				Name:             room.Name,
 				SpaceID:          room.SpaceID,
 				IsOccupied:       isRoomOccupied(room.SpaceID, activeConferences),
 				ParticipantCount: getRoomParticipantCount(room.SpaceID, activeConferences),
@@ -64,30 +93,67 @@ func ListRoomsHTMLHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.Han
 			roomViews = append(roomViews, roomView)
 		}
 
-		c.HTML(http.StatusOK, "list.html", gin.H{
-			"rooms": roomViews,
+		// Salle personnelle de l'utilisateur, pour afficher son lien public s'il l'a déjà ouverte
+		cfg := cfgStore.Get()
+		var personalRoom *models.PersonalRoom
+		if cfg.PersonalRoomsEnabled {
+			if personalRoom, err = models.GetPersonalRoomByEmail(c.Request.Context(), db, c.GetString(PrincipalKey)); err != nil {
+				respondErrorText(c, http.StatusInternalServerError, "Unable to retrieve personal room", err)
+				return
+			}
+		}
+
+		renderHTML(c, http.StatusOK, "list.html", gin.H{
+			"csrfToken":     csrfToken(c),
+			"rooms":         roomViews,
+			"isAdmin":       current == rbac.Admin,
+			"canCreate":     canSelfServeRooms(cfg, email),
+			"approval":      cfg.RoomApprovalRequired,
+			"personalRooms": cfg.PersonalRoomsEnabled,
+			"personalRoom":  personalRoom,
 		})
 	}
 }
 
 // GET /:slug
-func RedirectHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
+// Les rooms non publiques exigent une connexion (redirection vers Google OAuth) et, pour les rooms
+// restreintes, que l'utilisateur figure parmi les personnes autorisées. Un lien invité valide dispense de ces vérifications.
+func RedirectHandler(db *sql.DB, meetService *googleapi.MeetClient, resolver *rbac.Resolver, signer *guestlink.Signer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		slug := c.Param("slug")
 
-		room, err := models.GetRoomBySlug(db, slug)
+		room, err := models.GetRoomBySlug(c.Request.Context(), db, slug)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error verifying room existence"})
+			respondError(c, http.StatusInternalServerError, "Error verifying room existence", err, slog.String("slug", slug))
 			return
 		}
 		if room == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.String("slug", slug))
 			return
 		}
+		if room.ArchivedAt != nil {
+			respondErrorPage(c, http.StatusGone, "Room archived", "Cette salle a été archivée.", nil, slog.Int("room_id", room.ID))
+			return
+		}
+		// Le lien invité n'est consommé qu'une fois l'espace Meet résolu
+		guestLinkID := 0
+		if room.AccessPolicy != models.AccessPublic {
+			if token := c.Query(guestlink.QueryParam); token != "" && signer != nil {
+				var ok bool
+				if guestLinkID, ok = checkGuestLink(c, db, signer, room, token); !ok {
+					return
+				}
+			} else if !checkRoomAccess(c, db, resolver, room) {
+				return
+			}
+		}
 
-		space, err := meetService.GetSpace(room.SpaceID)
+		space, err := meetService.GetSpace(c.Request.Context(), room.SpaceID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve Google Meet space", "details": err.Error()})
+			respondError(c, http.StatusInternalServerError, "Failed to retrieve Google Meet space", err, slog.Int("room_id", room.ID), slog.String("space_id", room.SpaceID))
+			return
+		}
+		if guestLinkID != 0 && !useGuestLink(c, db, room, guestLinkID) {
 			return
 		}
 
@@ -96,32 +162,39 @@ func RedirectHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerF
 	}
 }
 
-// GET /healthz
-func HealthzHandler(db *sql.DB, meetService *googleapi.MeetClient) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Vérifier la connexion à la base de données
-		err := db.Ping()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": "unhealthy",
-				"error":  "Database connection failed",
-			})
-			return
-		}
+// checkRoomAccess applique la politique d'accès d'une room non publique avant la redirection.
+// Elle répond elle-même (connexion ou accès refusé) et renvoie false si l'utilisateur ne peut pas entrer.
+func checkRoomAccess(c *gin.Context, db *sql.DB, resolver *rbac.Resolver, room *models.Room) bool {
+	// Sans interface web, personne ne peut se connecter : seules les rooms publiques sont accessibles
+	if _, ok := c.Get(sessions.DefaultKey); !ok {
+		respondErrorPage(c, http.StatusForbidden, "Room requires sign-in but the web UI is disabled", "Cette room est réservée aux utilisateurs connectés.", nil, slog.Int("room_id", room.ID))
+		return false
+	}
+	if !authenticate(c) {
+		return false
+	}
 
-		// Vérifier l'accès à l'API Google Meet
-		err = meetService.CheckMeetClient()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"status": "unhealthy",
-				"error":  "Google Meet service unavailable",
-			})
-			return
-		}
+	email := c.GetString(ActorKey)
+	role, err := resolver.Role(c.Request.Context(), email)
+	if err != nil {
+		respondErrorPage(c, http.StatusServiceUnavailable, "Unable to resolve user role", "Vos droits d'accès n'ont pas pu être vérifiés. Veuillez réessayer.", err)
+		return false
+	}
+	entries, err := models.GetRoomAccess(c.Request.Context(), db, room.ID)
+	if err != nil {
+		respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve room access", "Vos droits d'accès n'ont pas pu être vérifiés. Veuillez réessayer.", err, slog.Int("room_id", room.ID))
+		return false
+	}
 
-		// Si tout va bien, renvoyer un statut healthy
-		c.JSON(http.StatusOK, gin.H{
-			"status": "healthy",
-		})
+	allowed, err := resolver.CanAccessRoom(c.Request.Context(), email, role, *room, entries)
+	if err != nil {
+		respondErrorPage(c, http.StatusServiceUnavailable, "Unable to check room access", "Vos droits d'accès n'ont pas pu être vérifiés. Veuillez réessayer.", err, slog.Int("room_id", room.ID))
+		return false
+	}
+	if !allowed {
+		respondErrorPage(c, http.StatusForbidden, "Room access denied", "Vous n'avez pas accès à cette room. Demandez à son propriétaire de vous l'ouvrir.", nil,
+			slog.Int("room_id", room.ID), slog.String("access_policy", room.AccessPolicy))
+		return false
 	}
+	return true
 }
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Nombre de slugs essayés (prenom.nom, prenom.nom-2, ...) avant d'abandonner la création d'une salle personnelle
+const maxPersonalSlugAttempts = 20
+
+// Durée d'inutilisation proposée par défaut pour le nettoyage des salles personnelles
+const defaultPersonalRoomUnusedDays = 90
+
+var (
+	errPersonalRoomNotFound = errors.New("personal room not found")
+	errPersonalRoomConflict = errors.New("user already has a personal room")
+)
+
+// errPersonalSlugInUse signale un slug déjà porté par une autre salle personnelle.
+var errPersonalSlugInUse = fieldError{"slug", "A personal room with the same slug already exists", "Ce lien est déjà utilisé par une autre salle personnelle."}
+
+// GET /me
+// Redirige l'utilisateur connecté vers sa salle personnelle, créée avec son espace Meet à la première visite.
+func MyRoomHandler(db *sql.DB, meetService *googleapi.MeetClient, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfgStore.Get().PersonalRoomsEnabled {
+			respondErrorPage(c, http.StatusNotFound, "Personal rooms disabled", "Les salles personnelles ne sont pas activées.", nil)
+			return
+		}
+		email := c.GetString(PrincipalKey)
+
+		room, err := models.GetPersonalRoomByEmail(c.Request.Context(), db, email)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Error querying for personal room", "Votre salle personnelle n'a pas pu être chargée.", err)
+			return
+		}
+		if room == nil {
+			if room, err = createPersonalRoom(c, db, meetService, email); err != nil {
+				respondErrorPage(c, http.StatusInternalServerError, "Error creating personal room", "Votre salle personnelle n'a pas pu être créée.", err)
+				return
+			}
+		}
+		redirectToPersonalRoom(c, db, meetService, room)
+	}
+}
+
+// GET /u/:slug
+// Lien public de la salle personnelle, accessible sans connexion comme une room publique.
+func PersonalRoomRedirectHandler(db *sql.DB, meetService *googleapi.MeetClient, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		slug := c.Param("slug")
+		if !cfgStore.Get().PersonalRoomsEnabled {
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.String("slug", slug))
+			return
+		}
+
+		room, err := models.GetPersonalRoomBySlug(c.Request.Context(), db, slug)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error verifying room existence", err, slog.String("slug", slug))
+			return
+		}
+		if room == nil {
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.String("slug", slug))
+			return
+		}
+		redirectToPersonalRoom(c, db, meetService, room)
+	}
+}
+
+// GET /api/personal-rooms
+func ListPersonalRoomsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rooms, err := models.GetPersonalRooms(c.Request.Context(), db)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve personal rooms", err)
+			return
+		}
+		if rooms == nil {
+			rooms = []models.PersonalRoom{}
+		}
+		c.JSON(http.StatusOK, rooms)
+	}
+}
+
+// PUT /api/personal-rooms/:id
+// Réattribue la salle à owner_email ; le slug est conservé s'il n'est pas précisé.
+func ReassignPersonalRoomHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid personal room ID", err, slog.String("personal_room_id", c.Param("id")))
+			return
+		}
+		var requestBody struct {
+			OwnerEmail string `json:"owner_email" binding:"required"`
+			Slug       string `json:"slug"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("personal_room_id", id))
+			return
+		}
+
+		room, err := reassignPersonalRoom(c, db, id, requestBody.OwnerEmail, requestBody.Slug)
+		if err != nil {
+			status, message := personalRoomErrorStatus(err), "Error reassigning personal room"
+			if status < http.StatusInternalServerError {
+				message = err.Error()
+			}
+			respondError(c, status, message, err, slog.Int("personal_room_id", id))
+			return
+		}
+		c.JSON(http.StatusOK, room)
+	}
+}
+
+// DELETE /api/personal-rooms/:id
+// Le propriétaire retrouvera une nouvelle salle, avec un nouvel espace Meet, à sa prochaine visite de /me.
+func DeletePersonalRoomHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid personal room ID", err, slog.String("personal_room_id", c.Param("id")))
+			return
+		}
+		if err := deletePersonalRoom(c, db, id); err != nil {
+			respondError(c, personalRoomErrorStatus(err), "Error deleting personal room", err, slog.Int("personal_room_id", id))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Personal room deleted successfully"})
+	}
+}
+
+// POST /api/personal-rooms/cleanup
+// Supprime les salles personnelles inutilisées depuis unused_days jours.
+func CleanupPersonalRoomsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody struct {
+			UnusedDays int `json:"unused_days" binding:"required,min=1"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "unused_days must be a positive number of days", err)
+			return
+		}
+
+		deleted, err := cleanupPersonalRooms(c, db, requestBody.UnusedDays)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error cleaning up personal rooms", err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+	}
+}
+
+// GET /admin/personal-rooms
+func ListPersonalRoomsHTMLHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rooms, err := models.GetPersonalRooms(c.Request.Context(), db)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve personal rooms", "Les salles personnelles n'ont pas pu être chargées.", err)
+			return
+		}
+
+		renderHTML(c, http.StatusOK, "admin_personal_rooms.html", gin.H{
+			"csrfToken":  csrfToken(c),
+			"rooms":      rooms,
+			"unusedDays": defaultPersonalRoomUnusedDays,
+			"deleted":    c.Query("deleted"),
+		})
+	}
+}
+
+// POST /admin/personal-rooms/:id
+func ReassignPersonalRoomFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid personal room ID", "Cette salle n'existe pas.", err)
+			return
+		}
+		if _, err := reassignPersonalRoom(c, db, id, c.PostForm("owner_email"), c.PostForm("slug")); err != nil {
+			respondErrorPage(c, personalRoomErrorStatus(err), "Error reassigning personal room", personalRoomErrorMessage(err, "La salle n'a pas pu être réattribuée."), err,
+				slog.Int("personal_room_id", id))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/personal-rooms")
+	}
+}
+
+// POST /admin/personal-rooms/:id/delete
+func DeletePersonalRoomFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid personal room ID", "Cette salle n'existe pas.", err)
+			return
+		}
+		if err := deletePersonalRoom(c, db, id); err != nil {
+			respondErrorPage(c, personalRoomErrorStatus(err), "Error deleting personal room", personalRoomErrorMessage(err, "La salle n'a pas pu être supprimée."), err,
+				slog.Int("personal_room_id", id))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/personal-rooms")
+	}
+}
+
+// POST /admin/personal-rooms/cleanup
+func CleanupPersonalRoomsFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days, err := strconv.Atoi(c.PostForm("unused_days"))
+		if err != nil || days < 1 {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid unused_days", "Indiquez un nombre de jours positif.", err)
+			return
+		}
+		deleted, err := cleanupPersonalRooms(c, db, days)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Error cleaning up personal rooms", "Les salles personnelles n'ont pas pu être nettoyées.", err)
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/personal-rooms?deleted="+strconv.FormatInt(deleted, 10))
+	}
+}
+
+// redirectToPersonalRoom redirige vers l'espace Meet de la salle et enregistre son utilisation.
+func redirectToPersonalRoom(c *gin.Context, db *sql.DB, meetService *googleapi.MeetClient, room *models.PersonalRoom) {
+	space, err := meetService.GetSpace(c.Request.Context(), room.SpaceID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to retrieve Google Meet space", err, slog.Int("personal_room_id", room.ID), slog.String("space_id", room.SpaceID))
+		return
+	}
+	// Une date d'utilisation manquée ne doit pas empêcher d'entrer dans la salle
+	if err := models.TouchPersonalRoom(c.Request.Context(), db, room.ID); err != nil {
+		slog.WarnContext(c.Request.Context(), "Unable to record personal room use", slog.Int("personal_room_id", room.ID), slog.Any("error", err))
+	}
+	c.Redirect(http.StatusFound, space.MeetingUri)
+}
+
+// createPersonalRoom crée l'espace Meet de l'utilisateur puis sa salle, sous le premier slug libre dérivé de son adresse.
+// La création est verrouillée par utilisateur : une requête concurrente attend et renvoie la salle ainsi créée.
+func createPersonalRoom(c *gin.Context, db *sql.DB, meetService *googleapi.MeetClient, email string) (*models.PersonalRoom, error) {
+	ctx := c.Request.Context()
+	base := personalSlug(email)
+
+	var room *models.PersonalRoom
+	var spaceID string
+	created := false
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		if err := models.LockPersonalRoom(ctx, tx, email); err != nil {
+			return err
+		}
+		var err error
+		if room, err = models.GetPersonalRoomByEmail(ctx, tx, email); err != nil || room != nil {
+			return err
+		}
+
+		space, err := meetService.CreateSpace(ctx)
+		if err != nil {
+			return fmt.Errorf("creating Google Meet space: %w", err)
+		}
+		spaceID = space.Name
+
+		for attempt := 1; attempt <= maxPersonalSlugAttempts; attempt++ {
+			slug := base
+			if attempt > 1 {
+				slug = fmt.Sprintf("%s-%d", base, attempt)
+			}
+			// Un slug pris par une autre salle personnelle renvoie nil : essayer le suivant
+			if room, err = models.CreatePersonalRoom(ctx, tx, models.PersonalRoom{OwnerEmail: email, Slug: slug, SpaceID: spaceID}); err != nil {
+				return fmt.Errorf("inserting personal room: %w", err)
+			}
+			if room != nil {
+				created = true
+				return recordPersonalRoomAudit(ctx, c, tx, models.AuditPersonalRoomCreate, nil, room)
+			}
+		}
+		return fmt.Errorf("no free personal room slug for %q after %d attempts", base, maxPersonalSlugAttempts)
+	})
+	if err != nil {
+		if spaceID != "" {
+			// L'espace Meet n'est rattaché à aucune salle : il est signalé pour être supprimé à la main
+			slog.ErrorContext(ctx, "Google Meet space orphaned by a failed personal room creation", slog.String("space_id", spaceID),
+				slog.String("slug", base), slog.Any("error", err))
+		}
+		return nil, err
+	}
+
+	if created {
+		slog.InfoContext(ctx, "Personal room created", slog.String("actor", actor(c)), slog.Int("personal_room_id", room.ID), slog.String("slug", room.Slug))
+	}
+	return room, nil
+}
+
+// personalSlug dérive le slug public de l'adresse : prenom.nom@example.test donne prenom.nom.
+// Un éventuel +suffixe est ignoré, les accents sont retirés et les autres caractères deviennent des tirets.
+func personalSlug(email string) string {
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	local, _, _ = strings.Cut(local, "+")
+
+	// Décomposer les lettres accentuées pour n'en garder que la lettre de base : élodie donne elodie
+	var slug strings.Builder
+	var separator byte
+	for _, r := range norm.NFD.String(local) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			if separator != 0 && slug.Len() > 0 {
+				slug.WriteByte(separator)
+			}
+			separator = 0
+			slug.WriteRune(r)
+		case separator != 0:
+			// Un seul séparateur entre deux caractères
+		case r == '.', r == '_':
+			separator = byte(r)
+		default:
+			separator = '-'
+		}
+	}
+
+	// Laisser la place d'un suffixe -NN
+	result := slug.String()
+	if len(result) > maxSlugLength-3 {
+		result = strings.TrimRight(result[:maxSlugLength-3], "._-")
+	}
+	if len(result) < minSlugLength {
+		// Adresse sans lettre ni chiffre exploitable : un suffixe tiré de l'adresse évite que tous ces utilisateurs
+		// se disputent le même slug
+		sum := sha256.Sum256([]byte(strings.ToLower(email)))
+		return "user-" + hex.EncodeToString(sum[:4])
+	}
+	return result
+}
+
+// reassignPersonalRoom donne la salle à un autre utilisateur et change éventuellement son slug.
+func reassignPersonalRoom(c *gin.Context, db *sql.DB, id int, ownerEmail, slug string) (*models.PersonalRoom, error) {
+	ctx := c.Request.Context()
+	ownerEmail, ok := bareAddress(ownerEmail)
+	if !ok {
+		return nil, fieldErrors{{"owner_email", "owner_email must be an email address", "Indiquez l'adresse email du nouveau propriétaire."}}
+	}
+
+	var room *models.PersonalRoom
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		before, err := models.GetPersonalRoomByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errPersonalRoomNotFound
+		}
+		after := *before
+		room = &after
+
+		if !strings.EqualFold(ownerEmail, room.OwnerEmail) {
+			// Même verrou que la première visite de /me : le nouveau propriétaire ne peut pas obtenir une salle entre-temps
+			if err := models.LockPersonalRoom(ctx, tx, ownerEmail); err != nil {
+				return err
+			}
+			existing, err := models.GetPersonalRoomByEmail(ctx, tx, ownerEmail)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return errPersonalRoomConflict
+			}
+		}
+		if slug = strings.TrimSpace(slug); slug != "" && slug != room.Slug {
+			if err := validatePersonalSlug(slug); err != nil {
+				return err
+			}
+			existing, err := models.GetPersonalRoomBySlug(ctx, tx, slug)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				return fieldErrors{errPersonalSlugInUse}
+			}
+			room.Slug = slug
+		}
+
+		room.OwnerEmail = ownerEmail
+		if err := models.UpdatePersonalRoom(ctx, tx, *room); err != nil {
+			if errors.Is(err, models.ErrSlugTaken) {
+				// Le slug a été pris par une création concurrente depuis la vérification
+				return fieldErrors{errPersonalSlugInUse}
+			}
+			return err
+		}
+		return recordPersonalRoomAudit(ctx, c, tx, models.AuditPersonalRoomReassign, before, room)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Personal room reassigned", slog.String("actor", actor(c)), slog.Int("personal_room_id", id), slog.String("owner_email", room.OwnerEmail), slog.String("slug", room.Slug))
+	return room, nil
+}
+
+// bareAddress renvoie l'adresse saisie si elle est une adresse email seule, sans nom affiché ni chevrons :
+// c'est elle qui est enregistrée et comparée à l'adresse de l'utilisateur connecté.
+func bareAddress(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	addr, err := mail.ParseAddress(input)
+	if err != nil || addr.Address != input {
+		return "", false
+	}
+	return addr.Address, true
+}
+
+// validatePersonalSlug applique les règles de saisie des rooms ; les slugs personnels, sous /u/, ne sont pas réservés.
+func validatePersonalSlug(slug string) error {
+	switch {
+	case len(slug) < minSlugLength || len(slug) > maxSlugLength:
+		return fieldErrors{{"slug", fmt.Sprintf("slug must be %d to %d characters long", minSlugLength, maxSlugLength),
+			fmt.Sprintf("Le lien doit compter de %d à %d caractères.", minSlugLength, maxSlugLength)}}
+	case !slugPattern.MatchString(slug):
+		return fieldErrors{{"slug", "slug must be lowercase letters and digits separated by '.', '-' or '_'",
+			"Le lien ne peut contenir que des lettres minuscules et des chiffres, séparés par un point, un tiret ou un tiret bas."}}
+	}
+	return nil
+}
+
+func deletePersonalRoom(c *gin.Context, db *sql.DB, id int) error {
+	ctx := c.Request.Context()
+	var room *models.PersonalRoom
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if room, err = models.GetPersonalRoomByID(ctx, tx, id); err != nil {
+			return err
+		}
+		if room == nil {
+			return errPersonalRoomNotFound
+		}
+		if err := models.DeletePersonalRoom(ctx, tx, id); err != nil {
+			return err
+		}
+		return recordPersonalRoomAudit(ctx, c, tx, models.AuditPersonalRoomDelete, room, nil)
+	})
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "Personal room deleted", slog.String("actor", actor(c)), slog.Int("personal_room_id", id), slog.String("owner_email", room.OwnerEmail))
+	return nil
+}
+
+func cleanupPersonalRooms(c *gin.Context, db *sql.DB, unusedDays int) (int64, error) {
+	ctx := c.Request.Context()
+	var deleted []models.PersonalRoom
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if deleted, err = models.DeleteUnusedPersonalRooms(ctx, tx, time.Now().AddDate(0, 0, -unusedDays)); err != nil {
+			return err
+		}
+		for i := range deleted {
+			if err := recordPersonalRoomAudit(ctx, c, tx, models.AuditPersonalRoomCleanup, &deleted[i], nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	slog.InfoContext(ctx, "Unused personal rooms deleted", slog.String("actor", actor(c)), slog.Int("unused_days", unusedDays), slog.Int("deleted", len(deleted)))
+	return int64(len(deleted)), nil
+}
+
+func personalRoomErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errPersonalRoomNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errPersonalRoomConflict):
+		return http.StatusConflict
+	}
+	return roomErrorStatus(err)
+}
+
+func personalRoomErrorMessage(err error, fallback string) string {
+	var invalid fieldErrors
+	switch {
+	case errors.Is(err, errPersonalRoomNotFound):
+		return "Cette salle n'existe pas."
+	case errors.Is(err, errPersonalRoomConflict):
+		return "Cet utilisateur a déjà une salle personnelle."
+	case errors.As(err, &invalid):
+		return invalid.userMessage()
+	}
+	return fallback
+}
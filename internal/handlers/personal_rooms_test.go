@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPersonalSlug(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"prenom.nom@example.test", "prenom.nom"},
+		{"Prenom.Nom+meet@example.test", "prenom.nom"},
+		{"élodie.müller@example.test", "elodie.muller"},
+		{"jean--paul__o'brien@example.test", "jean-paul_o-brien"},
+		{"x@example.test", ""},
+		{strings.Repeat("a", 80) + "@example.test", strings.Repeat("a", maxSlugLength-3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			got := personalSlug(tt.email)
+			if tt.want == "" {
+				// Repli dérivé de l'adresse
+				if !strings.HasPrefix(got, "user-") || validatePersonalSlug(got) != nil {
+					t.Fatalf("personalSlug(%q) = %q, want a valid user-<hash> fallback", tt.email, got)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("personalSlug(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersonalSlugFallbackIsPerUser(t *testing.T) {
+	first, second := personalSlug("x@example.test"), personalSlug("_@example.test")
+	if first == second {
+		t.Fatalf("personalSlug() = %q for two different addresses, want distinct fallbacks", first)
+	}
+	if personalSlug("X@Example.test") != first {
+		t.Fatal("personalSlug() fallback depends on the address case")
+	}
+}
+
+func TestBareAddress(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"alice@example.test", "alice@example.test"},
+		{"  alice@example.test ", "alice@example.test"},
+		{"Alice <alice@example.test>", ""},
+		{"<alice@example.test>", ""},
+		{"alice", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, ok := bareAddress(tt.input)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("bareAddress(%q) = %q, %v, want %q", tt.input, got, ok, tt.want)
+		}
+	}
+}
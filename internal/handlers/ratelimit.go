@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"groom/internal/config"
+	"groom/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Clés de limitation de débit
+const (
+	LimitByIP        = "ip"         // adresse IP du client
+	LimitByAPIClient = "api_client" // clé d'API ou sujet du token JWT, après l'authentification de l'API
+	LimitByUser      = "user"       // utilisateur connecté, après RequireLogin
+)
+
+var rateLimitRejections, _ = otel.Meter("groom/handlers").Int64Counter("groom.ratelimit.rejected",
+	metric.WithDescription("Requests rejected by the rate limiter"), metric.WithUnit("{request}"))
+
+// Middleware limitant le nombre de requêtes par fenêtre pour la clé choisie (LimitByIP, LimitByAPIClient, LimitByUser).
+// Au-delà, il répond 429 avec l'en-tête Retry-After. Si les compteurs sont indisponibles, la requête est laissée passer.
+func RateLimit(limiter ratelimit.Limiter, cfgStore *config.Store, by string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := cfgStore.Get()
+		rule := ratelimit.Rule{Window: cfg.RateLimitWindow}
+		var subject string
+		switch by {
+		case LimitByIP:
+			rule.Limit, subject = cfg.RateLimitPerIP, c.ClientIP()
+		case LimitByAPIClient:
+			rule.Limit, subject = cfg.RateLimitPerAPIKey, actor(c)
+		case LimitByUser:
+			rule.Limit, subject = cfg.RateLimitPerUser, strings.ToLower(c.GetString(ActorKey))
+		}
+		if !cfg.RateLimitEnabled || rule.Limit <= 0 || subject == "" {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), by+":"+subject, rule)
+		if err != nil {
+			logError(c, http.StatusServiceUnavailable, "Rate limiter unavailable, request allowed", err, slog.String("limit", by))
+			c.Next()
+			return
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if result.Allowed {
+			c.Next()
+			return
+		}
+
+		rateLimitRejections.Add(c.Request.Context(), 1, metric.WithAttributes(
+			attribute.String("limit", by),
+			attribute.String("route", c.FullPath()),
+		))
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+
+		attrs := []any{slog.String("limit", by), slog.String("subject", subject), slog.Duration("retry_after", result.RetryAfter)}
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			respondError(c, http.StatusTooManyRequests, "Too many requests", nil, attrs...)
+		} else {
+			respondErrorPage(c, http.StatusTooManyRequests, "Too many requests", "Vous avez envoyé trop de requêtes. Veuillez patienter quelques instants avant de réessayer.", nil, attrs...)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+
+	"groom/internal/apikey"
+	"groom/internal/models"
+	"groom/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware déterminant le rôle de l'utilisateur connecté, après RequireLogin. Les scopes équivalents
+// sont exposés comme pour un client de l'API.
+func LoadRole(resolver *rbac.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		email := c.GetString(ActorKey)
+		role, err := resolver.Role(c.Request.Context(), email)
+		if err != nil {
+			respondErrorPage(c, http.StatusServiceUnavailable, "Unable to resolve user role", "Vos droits d'accès n'ont pas pu être vérifiés. Veuillez réessayer.", err)
+			return
+		}
+
+		c.Set(RoleKey, role)
+		c.Set(ScopesKey, role.Scopes())
+		c.Set(PrincipalKey, email)
+		c.Next()
+	}
+}
+
+// Middleware réservant une page aux utilisateurs ayant au moins le rôle demandé, après LoadRole
+func RequireRole(required rbac.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get(RoleKey)
+		if current, _ := role.(rbac.Role); !current.Includes(required) {
+			respondErrorPage(c, http.StatusForbidden, "Insufficient role", "Votre rôle ne permet pas d'accéder à cette page.", nil,
+				slog.String("role", string(current)), slog.String("required_role", string(required)))
+			return
+		}
+		c.Next()
+	}
+}
+
+// canManageRoom indique si la requête peut modifier la room : les administrateurs modifient toutes les rooms,
+// les autres uniquement celles dont ils sont propriétaires.
+func canManageRoom(c *gin.Context, room *models.Room) bool {
+	if apikey.HasScope(c.GetStringSlice(ScopesKey), apikey.ScopeAdmin) {
+		return true
+	}
+	principal := c.GetString(PrincipalKey)
+	return principal != "" && strings.EqualFold(room.OwnerEmail, principal)
+}
+
+// GET /api/role-bindings
+func ListRoleBindingsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bindings, err := models.GetAllRoleBindings(c.Request.Context(), db)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve role bindings", err)
+			return
+		}
+		if bindings == nil {
+			bindings = []models.RoleBinding{}
+		}
+		c.JSON(http.StatusOK, bindings)
+	}
+}
+
+// POST /api/role-bindings
+func SaveRoleBindingHandler(db *sql.DB, resolver *rbac.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var requestBody struct {
+			SubjectType string `json:"subject_type" binding:"required"`
+			Subject     string `json:"subject" binding:"required"`
+			Role        string `json:"role" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err)
+			return
+		}
+		if requestBody.SubjectType != models.SubjectUser && requestBody.SubjectType != models.SubjectGroup {
+			respondError(c, http.StatusBadRequest, "subject_type must be user or group", nil)
+			return
+		}
+		if _, err := mail.ParseAddress(requestBody.Subject); err != nil {
+			respondError(c, http.StatusBadRequest, "subject must be an email address", err)
+			return
+		}
+		role, err := rbac.ParseRole(requestBody.Role)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+
+		binding, err := models.SaveRoleBinding(c.Request.Context(), db, models.RoleBinding{
+			SubjectType: requestBody.SubjectType,
+			Subject:     requestBody.Subject,
+			Role:        string(role),
+			CreatedBy:   actor(c),
+		})
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error saving role binding", err, slog.String("subject", requestBody.Subject))
+			return
+		}
+		resolver.Invalidate()
+
+		slog.InfoContext(c.Request.Context(), "Role binding saved", slog.String("actor", actor(c)),
+			slog.String("subject_type", binding.SubjectType), slog.String("subject", binding.Subject), slog.String("role", binding.Role))
+		c.JSON(http.StatusOK, binding)
+	}
+}
+
+// DELETE /api/role-bindings/:id
+func DeleteRoleBindingHandler(db *sql.DB, resolver *rbac.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid role binding ID", err, slog.String("role_binding_id", idStr))
+			return
+		}
+
+		deleted, err := models.DeleteRoleBinding(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error deleting role binding", err, slog.Int("role_binding_id", id))
+			return
+		}
+		if !deleted {
+			respondError(c, http.StatusNotFound, "Role binding not found", nil, slog.Int("role_binding_id", id))
+			return
+		}
+		resolver.Invalidate()
+
+		slog.InfoContext(c.Request.Context(), "Role binding deleted", slog.String("actor", actor(c)), slog.Int("role_binding_id", id))
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"slices"
+	"strconv"
+
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type roomAccessBody struct {
+	AccessPolicy string              `json:"access_policy"`
+	Access       []models.RoomAccess `json:"access"`
+}
+
+// GET /api/rooms/:id/access
+func GetRoomAccessHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid room ID", err, slog.String("room_id", idStr))
+			return
+		}
+
+		room, err := models.GetRoomByID(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error querying for room", err, slog.Int("room_id", id))
+			return
+		}
+		if room == nil {
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.Int("room_id", id))
+			return
+		}
+
+		entries, err := models.GetRoomAccess(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve room access", err, slog.Int("room_id", id))
+			return
+		}
+		if entries == nil {
+			entries = []models.RoomAccess{}
+		}
+		c.JSON(http.StatusOK, roomAccessBody{AccessPolicy: room.AccessPolicy, Access: entries})
+	}
+}
+
+// PUT /api/rooms/:id/access
+// Remplace la politique d'accès de la room et la liste des utilisateurs et groupes autorisés.
+func UpdateRoomAccessHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idStr := c.Param("id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid room ID", err, slog.String("room_id", idStr))
+			return
+		}
+
+		room, err := models.GetRoomByID(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error querying for room", err, slog.Int("room_id", id))
+			return
+		}
+		if room == nil {
+			respondError(c, http.StatusNotFound, "Room not found", nil, slog.Int("room_id", id))
+			return
+		}
+		if !canManageRoom(c, room) {
+			respondError(c, http.StatusForbidden, "You can only modify your own rooms", nil, slog.Int("room_id", id))
+			return
+		}
+
+		var requestBody roomAccessBody
+		if err := c.ShouldBindJSON(&requestBody); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("room_id", id))
+			return
+		}
+		if !slices.Contains(models.AccessPolicies, requestBody.AccessPolicy) {
+			respondError(c, http.StatusBadRequest, "access_policy must be one of public, domain, restricted", nil, slog.Int("room_id", id))
+			return
+		}
+		for _, entry := range requestBody.Access {
+			if entry.SubjectType != models.SubjectUser && entry.SubjectType != models.SubjectGroup {
+				respondError(c, http.StatusBadRequest, "subject_type must be user or group", nil, slog.Int("room_id", id))
+				return
+			}
+			if _, err := mail.ParseAddress(entry.Subject); err != nil {
+				respondError(c, http.StatusBadRequest, "subject must be an email address", err, slog.Int("room_id", id))
+				return
+			}
+		}
+
+		ctx := c.Request.Context()
+		err = models.WithTx(ctx, db, func(tx *sql.Tx) error {
+			// Relire l'état sous verrou : c'est lui que la modification remplace dans le journal d'audit
+			before, err := models.LockRoomState(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			if before == nil {
+				return errRoomNotFound
+			}
+			if err := models.SetRoomAccess(ctx, tx, id, requestBody.AccessPolicy, requestBody.Access); err != nil {
+				return err
+			}
+			after, err := models.GetRoomState(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+			return recordAudit(ctx, c, tx, models.AuditRoomAccess, id, before, after)
+		})
+		if err != nil {
+			respondError(c, roomErrorStatus(err), "Error updating room access", err, slog.Int("room_id", id))
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Room access updated", slog.String("actor", actor(c)), slog.Int("room_id", id),
+			slog.String("access_policy", requestBody.AccessPolicy), slog.Int("access_entries", len(requestBody.Access)))
+		c.JSON(http.StatusOK, gin.H{"message": "Room access updated successfully"})
+	}
+}
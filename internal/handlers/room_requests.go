@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+	"groom/internal/models"
+	"groom/internal/notify"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Délai d'envoi d'une notification ; un échec est journalisé sans annuler la décision.
+const notifyTimeout = 10 * time.Second
+
+var (
+	errRoomRequestNotFound = errors.New("room request not found")
+	errRoomRequestDecided  = errors.New("room request already decided")
+)
+
+// errSlugRequested signale un nom déjà demandé par une demande en attente.
+var errSlugRequested = fieldError{"slug", "A room with the same slug has already been requested", "Ce nom a déjà été demandé et attend une approbation."}
+
+// GET /api/room-requests
+// Filtre facultatif : status (pending, approved ou rejected).
+func ListRoomRequestsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		if status != "" && !slices.Contains(models.RequestStatuses, status) {
+			respondError(c, http.StatusBadRequest, "status must be one of pending, approved, rejected", nil, slog.String("status", status))
+			return
+		}
+
+		requests, err := models.GetRoomRequests(c.Request.Context(), db, status, "")
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve room requests", err)
+			return
+		}
+		if requests == nil {
+			requests = []models.RoomRequest{}
+		}
+		c.JSON(http.StatusOK, requests)
+	}
+}
+
+// POST /api/room-requests/:id/approve
+func ApproveRoomRequestHandler(db *sql.DB, meetService *googleapi.MeetClient, notifier *notify.Notifier, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid room request ID", err, slog.String("room_request_id", c.Param("id")))
+			return
+		}
+
+		request, err := approveRoomRequest(c, db, meetService, notifier, cfgStore.Get(), id)
+		if err != nil {
+			status, message := roomRequestErrorStatus(err), "Error approving room request"
+			if status < http.StatusInternalServerError {
+				message = err.Error()
+			}
+			respondError(c, status, message, err, slog.Int("room_request_id", id))
+			return
+		}
+		c.JSON(http.StatusOK, request)
+	}
+}
+
+// POST /api/room-requests/:id/reject
+// Le motif (reason) est facultatif et transmis au demandeur.
+func RejectRoomRequestHandler(db *sql.DB, notifier *notify.Notifier, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid room request ID", err, slog.String("room_request_id", c.Param("id")))
+			return
+		}
+		var requestBody struct {
+			Reason string `json:"reason"`
+		}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&requestBody); err != nil {
+				respondError(c, http.StatusBadRequest, "Invalid input", err, slog.Int("room_request_id", id))
+				return
+			}
+		}
+
+		request, err := rejectRoomRequest(c, db, notifier, cfgStore.Get(), id, requestBody.Reason)
+		if err != nil {
+			status, message := roomRequestErrorStatus(err), "Error rejecting room request"
+			if status < http.StatusInternalServerError {
+				message = err.Error()
+			}
+			respondError(c, status, message, err, slog.Int("room_request_id", id))
+			return
+		}
+		c.JSON(http.StatusOK, request)
+	}
+}
+
+// GET /admin/room-requests
+// Les demandes en attente sont affichées par défaut ; ?status= vide les affiche toutes.
+func ListRoomRequestsHTMLHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.RequestPending)
+		if status != "" && !slices.Contains(models.RequestStatuses, status) {
+			status = models.RequestPending
+		}
+		requests, err := models.GetRoomRequests(c.Request.Context(), db, status, "")
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve room requests", "Les demandes de salles n'ont pas pu être chargées.", err)
+			return
+		}
+
+		renderHTML(c, http.StatusOK, "room_requests.html", gin.H{
+			"csrfToken": csrfToken(c),
+			"requests":  requests,
+			"status":    status,
+			"isAdmin":   true,
+		})
+	}
+}
+
+// POST /admin/room-requests/:id/approve
+func ApproveRoomRequestFormHandler(db *sql.DB, meetService *googleapi.MeetClient, notifier *notify.Notifier, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid room request ID", "Cette demande n'existe pas.", err)
+			return
+		}
+		if _, err := approveRoomRequest(c, db, meetService, notifier, cfgStore.Get(), id); err != nil {
+			respondErrorPage(c, roomRequestErrorStatus(err), "Error approving room request", roomRequestErrorMessage(err, "La demande n'a pas pu être approuvée."), err,
+				slog.Int("room_request_id", id))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/room-requests")
+	}
+}
+
+// POST /admin/room-requests/:id/reject
+func RejectRoomRequestFormHandler(db *sql.DB, notifier *notify.Notifier, cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			respondErrorPage(c, http.StatusBadRequest, "Invalid room request ID", "Cette demande n'existe pas.", err)
+			return
+		}
+		if _, err := rejectRoomRequest(c, db, notifier, cfgStore.Get(), id, c.PostForm("reason")); err != nil {
+			respondErrorPage(c, roomRequestErrorStatus(err), "Error rejecting room request", roomRequestErrorMessage(err, "La demande n'a pas pu être refusée."), err,
+				slog.Int("room_request_id", id))
+			return
+		}
+		c.Redirect(http.StatusSeeOther, "/admin/room-requests")
+	}
+}
+
+// GET /rooms/requests
+// Demandes de l'utilisateur connecté et leur suivi.
+func ListMyRoomRequestsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requests, err := models.GetRoomRequests(c.Request.Context(), db, "", c.GetString(PrincipalKey))
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve room requests", "Vos demandes de salles n'ont pas pu être chargées.", err)
+			return
+		}
+
+		renderHTML(c, http.StatusOK, "room_requests.html", gin.H{
+			"requests": requests,
+			"isAdmin":  false,
+		})
+	}
+}
+
+// requestRoom vérifie la saisie et le quota puis enregistre une demande de room, sans créer d'espace Meet.
+func requestRoom(c *gin.Context, db *sql.DB, notifier *notify.Notifier, room models.Room, quota roomQuota) (*models.RoomRequest, error) {
+	ctx := c.Request.Context()
+	request := &models.RoomRequest{
+		Slug:           room.Slug,
+		Name:           room.Name,
+		Description:    room.Description,
+		AccessPolicy:   room.AccessPolicy,
+		Team:           room.Team,
+		RequesterEmail: room.OwnerEmail,
+	}
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		if err := validateRoom(ctx, tx, room, nil); err != nil {
+			return err
+		}
+		pending, err := models.HasPendingRoomRequest(ctx, tx, room.Slug)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return fieldErrors{errSlugRequested}
+		}
+		if err := checkRoomQuota(ctx, tx, room, quota); err != nil {
+			return err
+		}
+		created, err := models.CreateRoomRequest(ctx, tx, request)
+		if err != nil {
+			return err
+		}
+		if !created {
+			// Le nom a été demandé par une demande concurrente depuis la vérification
+			return fieldErrors{errSlugRequested}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Room requested", slog.String("actor", actor(c)), slog.Int("room_request_id", request.ID), slog.String("slug", request.Slug))
+	sendNotification(c, notifier, notify.Event{
+		Type:    "room_request.created",
+		Subject: "Nouvelle demande de salle : " + request.Slug,
+		Text:    fmt.Sprintf("%s demande la salle %s.", request.RequesterEmail, request.Slug),
+		Data:    request,
+	})
+	return request, nil
+}
+
+// approveRoomRequest crée la room demandée, comme CreateRoomHandler, et marque la demande approuvée
+// dans la même transaction ; le demandeur en devient propriétaire et est notifié.
+func approveRoomRequest(c *gin.Context, db *sql.DB, meetService *googleapi.MeetClient, notifier *notify.Notifier, cfg config.Config, id int) (*models.RoomRequest, error) {
+	request, err := pendingRoomRequest(c.Request.Context(), db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// La demande est verrouillée pendant la réservation du nom : une seconde approbation simultanée attend
+	// la première, puis trouve la demande déjà approuvée ou son nom réservé, sans créer d'espace Meet
+	lock := func(tx *sql.Tx) error {
+		locked, err := models.LockRoomRequest(c.Request.Context(), tx, id)
+		if err != nil {
+			return err
+		}
+		if locked == nil {
+			return errRoomRequestNotFound
+		}
+		if locked.Status != models.RequestPending {
+			return errRoomRequestDecided
+		}
+		return nil
+	}
+	room, err := createRoom(c, db, meetService, request.Room(), roomQuota{}, lock, func(tx *sql.Tx, created *models.Room) error {
+		request.Status = models.RequestApproved
+		request.DecidedBy = actor(c)
+		request.RoomID = &created.ID
+		decided, err := models.DecideRoomRequest(c.Request.Context(), tx, request)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return errRoomRequestDecided
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(c.Request.Context(), "Room request approved", slog.String("actor", actor(c)), slog.Int("room_request_id", id), slog.Int("room_id", room.ID))
+	sendNotification(c, notifier, notify.Event{
+		Type:    "room_request.approved",
+		To:      request.RequesterEmail,
+		Subject: "Votre salle " + room.Slug + " est prête",
+		Text:    fmt.Sprintf("Votre demande de salle a été approuvée. La salle est accessible à l'adresse %s.", roomURL(cfg.PublicURL, room.Slug)),
+		Data:    gin.H{"request": request, "room": room},
+	})
+	return request, nil
+}
+
+// rejectRoomRequest refuse la demande et notifie le demandeur, avec le motif s'il est donné.
+func rejectRoomRequest(c *gin.Context, db *sql.DB, notifier *notify.Notifier, cfg config.Config, id int, reason string) (*models.RoomRequest, error) {
+	request, err := pendingRoomRequest(c.Request.Context(), db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = models.RequestRejected
+	request.Reason = strings.TrimSpace(reason)
+	request.DecidedBy = actor(c)
+	decided, err := models.DecideRoomRequest(c.Request.Context(), db, request)
+	if err != nil {
+		return nil, err
+	}
+	if !decided {
+		return nil, errRoomRequestDecided
+	}
+
+	slog.InfoContext(c.Request.Context(), "Room request rejected", slog.String("actor", actor(c)), slog.Int("room_request_id", id))
+	text := fmt.Sprintf("Votre demande de la salle %s a été refusée.", request.Slug)
+	if request.Reason != "" {
+		text += "\n\nMotif : " + request.Reason
+	}
+	sendNotification(c, notifier, notify.Event{
+		Type:    "room_request.rejected",
+		To:      request.RequesterEmail,
+		Subject: "Votre demande de salle " + request.Slug + " a été refusée",
+		Text:    text,
+		Data:    request,
+	})
+	return request, nil
+}
+
+func pendingRoomRequest(ctx context.Context, db *sql.DB, id int) (*models.RoomRequest, error) {
+	request, err := models.GetRoomRequestByID(ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errRoomRequestNotFound
+	}
+	if request.Status != models.RequestPending {
+		return nil, errRoomRequestDecided
+	}
+	return request, nil
+}
+
+// sendNotification envoie l'événement sans faire échouer la requête : la décision est déjà enregistrée.
+func sendNotification(c *gin.Context, notifier *notify.Notifier, event notify.Event) {
+	if notifier == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), notifyTimeout)
+	defer cancel()
+	if err := notifier.Send(ctx, event); err != nil {
+		slog.WarnContext(ctx, "Unable to send notification", slog.String("type", event.Type), slog.Any("error", err))
+	}
+}
+
+// roomURL construit le lien d'une room, absolu si public_url est configurée.
+func roomURL(publicURL, slug string) string {
+	return strings.TrimRight(publicURL, "/") + "/" + url.PathEscape(slug)
+}
+
+func roomRequestErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errRoomRequestNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errRoomRequestDecided):
+		return http.StatusConflict
+	}
+	return roomErrorStatus(err)
+}
+
+func roomRequestErrorMessage(err error, fallback string) string {
+	var invalid fieldErrors
+	switch {
+	case errors.Is(err, errRoomRequestNotFound):
+		return "Cette demande n'existe pas."
+	case errors.Is(err, errRoomRequestDecided):
+		return "Cette demande a déjà été traitée."
+	case errors.As(err, &invalid):
+		return invalid.userMessage()
+	}
+	return fallback
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	googleapi "groom/internal/google"
+	"groom/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Règles de saisie des rooms, communes à l'API et aux pages d'administration
+const (
+	minSlugLength            = 2
+	maxSlugLength            = 64
+	maxRoomNameLength        = 255
+	maxRoomDescriptionLength = 2000
+)
+
+// Un slug est en minuscules, fait de lettres et de chiffres séparés par un point, un tiret ou un tiret bas.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+([._-][a-z0-9]+)*$`)
+
+// Premiers segments de chemin utilisés par groom, qui ne peuvent pas servir de slug
+var reservedSlugs = []string{"admin", "api", "auth", "healthz", "livez", "me", "readyz", "rooms", "u"}
+
+// Durée de la réservation du nom et du quota d'une room pendant la création de son espace Meet ; elle laisse
+// largement le temps à l'appel de répondre, et libère le nom d'une création interrompue (processus arrêté).
+const roomReservationTTL = 10 * time.Minute
+
+// roomQuota limite les rooms créées en libre-service ; une limite nulle est illimitée.
+type roomQuota struct {
+	PerUser int
+	PerTeam int
+}
+
+// fieldError est une règle de saisie non respectée, avec son message pour l'API et pour les formulaires.
+type fieldError struct {
+	Field       string
+	Message     string // renvoyé par l'API
+	UserMessage string // affiché sous le champ du formulaire
+}
+
+// errSlugInUse signale un slug déjà porté par une autre room.
+var errSlugInUse = fieldError{"slug", "A room with the same slug already exists", "Une autre salle porte déjà ce nom."}
+
+// fieldErrors regroupe les règles non respectées par une saisie.
+type fieldErrors []fieldError
+
+func (e fieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// userMessage renvoie les messages des formulaires en une phrase, pour les pages d'erreur.
+func (e fieldErrors) userMessage() string {
+	messages := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		messages = append(messages, fieldErr.UserMessage)
+	}
+	return strings.Join(messages, " ")
+}
+
+// byField renvoie les messages à afficher, par champ du formulaire.
+func (e fieldErrors) byField() map[string]string {
+	messages := make(map[string]string, len(e))
+	for _, fieldErr := range e {
+		if _, exists := messages[fieldErr.Field]; !exists {
+			messages[fieldErr.Field] = fieldErr.UserMessage
+		}
+	}
+	return messages
+}
+
+// validateRoom vérifie une room avant son enregistrement ; previous est l'état actuel (nil pour une création).
+// Un slug ou un espace Meet inchangé n'est pas revérifié, pour ne pas bloquer les rooms créées avant ces règles.
+func validateRoom(ctx context.Context, db models.DBTX, room models.Room, previous *models.Room) error {
+	var errs fieldErrors
+
+	if previous == nil || previous.Slug != room.Slug {
+		switch {
+		case room.Slug == "":
+			errs = append(errs, fieldError{"slug", "slug is required", "Le nom de la salle est obligatoire."})
+		case len(room.Slug) < minSlugLength || len(room.Slug) > maxSlugLength:
+			errs = append(errs, fieldError{"slug", fmt.Sprintf("slug must be %d to %d characters long", minSlugLength, maxSlugLength),
+				fmt.Sprintf("Le nom de la salle doit compter de %d à %d caractères.", minSlugLength, maxSlugLength)})
+		case !slugPattern.MatchString(room.Slug):
+			errs = append(errs, fieldError{"slug", "slug must be lowercase letters and digits separated by '.', '-' or '_'",
+				"Le nom de la salle ne peut contenir que des minuscules et des chiffres, séparés par un point, un tiret ou un tiret bas."})
+		case slices.Contains(reservedSlugs, room.Slug):
+			errs = append(errs, fieldError{"slug", "slug is reserved", "Ce nom est réservé par groom."})
+		default:
+			existing, err := models.GetRoomBySlug(ctx, db, room.Slug)
+			if err != nil {
+				return err
+			}
+			if existing != nil && existing.ID != room.ID {
+				errs = append(errs, errSlugInUse)
+				break
+			}
+			reserved, err := models.IsRoomSlugReserved(ctx, db, room.Slug)
+			if err != nil {
+				return err
+			}
+			if reserved {
+				errs = append(errs, errSlugInUse)
+			}
+		}
+	}
+	if (previous == nil || previous.SpaceID != room.SpaceID) && room.SpaceID != "" && !strings.HasPrefix(room.SpaceID, "spaces/") {
+		errs = append(errs, fieldError{"space_id", "space_id must be a Google Meet space name (spaces/...)", "L'espace Meet doit être de la forme spaces/…"})
+	}
+	if utf8.RuneCountInString(room.Name) > maxRoomNameLength {
+		errs = append(errs, fieldError{"name", fmt.Sprintf("name must be at most %d characters long", maxRoomNameLength),
+			fmt.Sprintf("Le titre ne peut pas dépasser %d caractères.", maxRoomNameLength)})
+	}
+	if utf8.RuneCountInString(room.Description) > maxRoomDescriptionLength {
+		errs = append(errs, fieldError{"description", fmt.Sprintf("description must be at most %d characters long", maxRoomDescriptionLength),
+			fmt.Sprintf("La description ne peut pas dépasser %d caractères.", maxRoomDescriptionLength)})
+	}
+	if !slices.Contains(models.AccessPolicies, room.AccessPolicy) {
+		errs = append(errs, fieldError{"access_policy", "access_policy must be one of public, domain, restricted", "Choisissez une politique d'accès."})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkRoomQuota vérifie que le propriétaire de la room et son équipe peuvent en créer une de plus.
+// Les demandes en attente d'approbation et les créations en cours sont comptées comme des rooms. Le comptage
+// se fait sous verrou, dans la transaction qui réserve la room ou enregistre la demande.
+func checkRoomQuota(ctx context.Context, tx *sql.Tx, room models.Room, quota roomQuota) error {
+	if quota == (roomQuota{}) {
+		return nil
+	}
+	if err := models.LockRoomQuota(ctx, tx, room.OwnerEmail, room.Team); err != nil {
+		return err
+	}
+	pendingByUser, pendingByTeam, err := models.CountPendingRoomRequests(ctx, tx, room.OwnerEmail, room.Team)
+	if err != nil {
+		return err
+	}
+	reservedByUser, reservedByTeam, err := models.CountRoomReservations(ctx, tx, room.OwnerEmail, room.Team)
+	if err != nil {
+		return err
+	}
+	pendingByUser, pendingByTeam = pendingByUser+reservedByUser, pendingByTeam+reservedByTeam
+
+	if quota.PerUser > 0 {
+		count, err := models.CountOwnedRooms(ctx, tx, room.OwnerEmail)
+		if err != nil {
+			return err
+		}
+		if count += pendingByUser; count >= quota.PerUser {
+			return fieldErrors{{"quota", fmt.Sprintf("You already own or requested %d rooms, the maximum allowed", count),
+				fmt.Sprintf("Vous avez déjà %d salles ou demandes en attente, le maximum autorisé. Archivez-en une ou demandez à un administrateur.", count)}}
+		}
+	}
+	if quota.PerTeam > 0 && room.Team != "" {
+		count, err := models.CountTeamRooms(ctx, tx, room.Team)
+		if err != nil {
+			return err
+		}
+		if count += pendingByTeam; count >= quota.PerTeam {
+			return fieldErrors{{"quota", fmt.Sprintf("Team %s already has %d rooms, the maximum allowed", room.Team, count),
+				fmt.Sprintf("L'équipe %s a déjà %d salles, le maximum autorisé.", room.Team, count)}}
+		}
+	}
+	return nil
+}
+
+// createRoom vérifie la saisie et le quota, crée l'espace Meet puis la room, enregistrée avec son événement d'audit.
+// Le nom et le quota sont réservés par une première transaction courte ; l'espace Meet est créé hors transaction,
+// puis une seconde transaction enregistre la room et libère la réservation.
+// before et then, facultatives, s'exécutent respectivement dans la première transaction (pour verrouiller ou
+// revérifier ce dont la création dépend) et dans la seconde, une fois la room enregistrée.
+func createRoom(c *gin.Context, db *sql.DB, meetService *googleapi.MeetClient, room models.Room, quota roomQuota,
+	before func(tx *sql.Tx) error, then func(tx *sql.Tx, created *models.Room) error) (*models.Room, error) {
+	ctx := c.Request.Context()
+
+	var reservation *models.RoomReservation
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		if before != nil {
+			if err := before(tx); err != nil {
+				return err
+			}
+		}
+		if err := validateRoom(ctx, tx, room, nil); err != nil {
+			return err
+		}
+		if err := checkRoomQuota(ctx, tx, room, quota); err != nil {
+			return err
+		}
+		var err error
+		if reservation, err = models.ReserveRoom(ctx, tx, room, time.Now().Add(roomReservationTTL)); err != nil {
+			return fmt.Errorf("reserving room: %w", err)
+		}
+		if reservation == nil {
+			// Le slug a été réservé par une création concurrente depuis la validation
+			return fieldErrors{errSlugInUse}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := meetService.CreateSpace(ctx)
+	if err != nil {
+		releaseRoomReservation(ctx, db, reservation)
+		return nil, fmt.Errorf("creating Google Meet space: %w", err)
+	}
+	room.SpaceID = space.Name
+
+	var created *models.Room
+	err = models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		if err := models.ReleaseRoomReservation(ctx, tx, reservation.ID); err != nil {
+			return err
+		}
+		var err error
+		if created, err = models.CreateRoom(ctx, tx, room); err != nil {
+			return fmt.Errorf("inserting room: %w", err)
+		}
+		if created == nil {
+			// La réservation a expiré et le slug a été repris entre-temps
+			return fieldErrors{errSlugInUse}
+		}
+		after := &models.RoomState{Room: *created, Access: []models.RoomAccess{}}
+		if err := recordAudit(ctx, c, tx, models.AuditRoomCreate, created.ID, nil, after); err != nil {
+			return fmt.Errorf("inserting room: %w", err)
+		}
+		if then != nil {
+			return then(tx, created)
+		}
+		return nil
+	})
+	if err != nil {
+		releaseRoomReservation(ctx, db, reservation)
+		// L'espace Meet n'est rattaché à aucune room : il est signalé pour être supprimé à la main
+		slog.ErrorContext(ctx, "Google Meet space orphaned by a failed room creation", slog.String("space_id", room.SpaceID),
+			slog.String("slug", room.Slug), slog.Any("error", err))
+		return nil, err
+	}
+	return created, nil
+}
+
+// releaseRoomReservation libère le nom et le quota d'une création abandonnée ; à défaut, la réservation expire.
+func releaseRoomReservation(ctx context.Context, db *sql.DB, reservation *models.RoomReservation) {
+	// La requête peut avoir été annulée : c'est souvent la raison de l'abandon
+	if err := models.ReleaseRoomReservation(context.WithoutCancel(ctx), db, reservation.ID); err != nil {
+		slog.WarnContext(ctx, "Unable to release room reservation", slog.String("slug", reservation.Slug), slog.Any("error", err))
+	}
+}
+
+// changeRoom applique change à la room dans une transaction, vérifie le résultat avec les règles de saisie
+// et enregistre la modification dans le journal d'audit sous l'action donnée.
+func changeRoom(c *gin.Context, db *sql.DB, id int, action string, change func(room *models.Room)) (*models.RoomState, error) {
+	ctx := c.Request.Context()
+	var after *models.RoomState
+
+	err := models.WithTx(ctx, db, func(tx *sql.Tx) error {
+		before, err := models.LockRoomState(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return errRoomNotFound
+		}
+
+		room := before.Room
+		change(&room)
+		if err := validateRoom(ctx, tx, room, &before.Room); err != nil {
+			return err
+		}
+		if err := models.UpdateRoom(ctx, tx, room); err != nil {
+			if errors.Is(err, models.ErrSlugTaken) {
+				// Le slug a été pris par une modification concurrente depuis la validation
+				return fieldErrors{errSlugInUse}
+			}
+			return err
+		}
+		after = &models.RoomState{Room: room, Access: before.Access}
+		return recordAudit(ctx, c, tx, action, id, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// roomErrorStatus donne le statut HTTP d'une erreur de createRoom ou changeRoom.
+func roomErrorStatus(err error) int {
+	var invalid fieldErrors
+	switch {
+	case errors.As(err, &invalid) && invalid.byField()["quota"] != "":
+		return http.StatusForbidden
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, errRoomNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
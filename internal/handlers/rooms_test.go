@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"groom/internal/models"
+)
+
+// Les cas ne changent pas le slug : validateRoom n'interroge pas la base.
+func TestValidateRoomUnchangedFields(t *testing.T) {
+	legacy := models.Room{ID: 1, Slug: "Ancienne_Salle", SpaceID: "abc-defg-hij", AccessPolicy: models.AccessPublic}
+
+	tests := []struct {
+		name      string
+		change    func(room *models.Room)
+		wantField string
+	}{
+		{"unchanged legacy room", func(room *models.Room) {}, ""},
+		{"renamed title", func(room *models.Room) { room.Name = "Salle du conseil" }, ""},
+		{"invalid new space", func(room *models.Room) { room.SpaceID = "xyz-abcd-efg" }, "space_id"},
+		{"valid new space", func(room *models.Room) { room.SpaceID = "spaces/AbCdEf" }, ""},
+		{"invalid access policy", func(room *models.Room) { room.AccessPolicy = "everyone" }, "access_policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := legacy
+			tt.change(&room)
+			err := validateRoom(context.Background(), nil, room, &legacy)
+
+			var invalid fieldErrors
+			switch {
+			case tt.wantField == "" && err != nil:
+				t.Fatalf("validateRoom() = %v, want no error", err)
+			case tt.wantField != "" && (!errors.As(err, &invalid) || invalid.byField()[tt.wantField] == ""):
+				t.Fatalf("validateRoom() = %v, want an error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
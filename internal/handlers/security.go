@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"groom/internal/config"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CSPNonceKey = "cspNonce"
+
+	csrfSessionKey = "csrf_token"
+	CSRFFieldName  = "csrf_token"   // champ caché des formulaires
+	CSRFHeader     = "X-CSRF-Token" // alternative pour les requêtes scriptées
+)
+
+// Middleware ajoutant les en-têtes de sécurité des pages HTML. Les balises <script> et <style> des templates
+// portent le nonce de la Content-Security-Policy, tiré au hasard à chaque requête.
+func SecurityHeaders(cfgStore *config.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := cfgStore.Get()
+		nonce := randomToken()
+		c.Set(CSPNonceKey, nonce)
+
+		header := c.Writer.Header()
+		header.Set("Content-Security-Policy", strings.Join([]string{
+			"default-src 'none'",
+			fmt.Sprintf("script-src 'nonce-%s'", nonce),
+			fmt.Sprintf("style-src 'nonce-%s'", nonce),
+			"img-src 'self' data:",
+			"connect-src 'self'",
+			// Les formulaires redirigent vers la connexion Google une fois la session fermée
+			"form-action 'self' https://accounts.google.com",
+			"base-uri 'none'",
+			"frame-ancestors 'none'",
+		}, "; "))
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "same-origin")
+		if cfg.HSTSMaxAge > 0 {
+			value := fmt.Sprintf("max-age=%d", int(cfg.HSTSMaxAge.Seconds()))
+			if cfg.HSTSIncludeSubdomains {
+				value += "; includeSubDomains"
+			}
+			header.Set("Strict-Transport-Security", value)
+		}
+
+		c.Next()
+	}
+}
+
+// Middleware refusant les requêtes (POST, etc.) dont le jeton CSRF ne correspond pas à celui de la session.
+// Le jeton est fourni par le champ caché csrf_token des formulaires ou par l'en-tête X-CSRF-Token.
+func RequireCSRF() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected, _ := sessions.Default(c).Get(csrfSessionKey).(string)
+		provided := c.GetHeader(CSRFHeader)
+		if provided == "" {
+			provided = c.PostForm(CSRFFieldName)
+		}
+		if expected == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			respondErrorPage(c, http.StatusForbidden, "Invalid CSRF token", "Le formulaire a expiré. Rechargez la page et réessayez.", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// csrfToken renvoie le jeton CSRF de la session, créé à la première page avec formulaire.
+func csrfToken(c *gin.Context) string {
+	if _, ok := c.Get(sessions.DefaultKey); !ok {
+		return ""
+	}
+	session := sessions.Default(c)
+	if token, ok := session.Get(csrfSessionKey).(string); ok && token != "" {
+		return token
+	}
+	token := randomToken()
+	session.Set(csrfSessionKey, token)
+	if err := session.Save(); err != nil {
+		logError(c, http.StatusInternalServerError, "Failed to save CSRF token", err)
+	}
+	return token
+}
+
+// renderHTML affiche un template en lui fournissant le nonce CSP (cspNonce).
+// Les pages contenant des formulaires ajoutent elles-mêmes csrfToken.
+func renderHTML(c *gin.Context, status int, name string, data gin.H) {
+	data["cspNonce"] = c.GetString(CSPNonceKey)
+	c.HTML(status, name, data)
+}
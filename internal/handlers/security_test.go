@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-contrib/sessions/cookie"
+	"github.com/gin-gonic/gin"
+)
+
+// newCSRFRouter sert une page qui émet le jeton CSRF (GET /form) et un formulaire protégé (POST /form).
+func newCSRFRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetHTMLTemplate(template.Must(template.New("error.html").Parse("{{.Message}}")))
+	r.Use(sessions.Sessions("groom", cookie.NewStore([]byte("0123456789abcdef0123456789abcdef"))))
+	r.GET("/form", func(c *gin.Context) {
+		c.String(http.StatusOK, csrfToken(c))
+	})
+	r.POST("/form", RequireCSRF(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return r
+}
+
+func TestRequireCSRF(t *testing.T) {
+	r := newCSRFRouter()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/form", nil))
+	token, cookies := rec.Body.String(), rec.Result().Cookies()
+	if token == "" || len(cookies) == 0 {
+		t.Fatal("no CSRF token issued")
+	}
+
+	tests := []struct {
+		name       string
+		withCookie bool
+		field      string
+		header     string
+		want       int
+	}{
+		{"form field", true, token, "", http.StatusNoContent},
+		{"header", true, "", token, http.StatusNoContent},
+		{"missing token", true, "", "", http.StatusForbidden},
+		{"mismatched token", true, token + "x", "", http.StatusForbidden},
+		{"mismatched header", true, token, "other", http.StatusForbidden},
+		{"session without token", false, token, "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.field != "" {
+				form.Set(CSRFFieldName, tt.field)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.withCookie {
+				for _, cookie := range cookies {
+					req.AddCookie(cookie)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+	"groom/internal/models"
+	"groom/internal/notify"
+	"groom/internal/rbac"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /rooms/new
+// Formulaire de création d'une room en libre-service, ouvert aux utilisateurs des domaines Workspace.
+func NewSelfServiceRoomHandler(cfgStore *config.Store, resolver *rbac.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := cfgStore.Get()
+		if !requireSelfService(c, cfg) {
+			return
+		}
+		teams, ok := userTeams(c, resolver)
+		if !ok {
+			return
+		}
+		selfServiceRoomForm(c, cfg, models.Room{AccessPolicy: cfg.RoomDefaultAccessPolicy}, teams)(http.StatusOK, nil)
+	}
+}
+
+// POST /rooms
+// Le créateur devient propriétaire de la room, dans la limite de room_max_per_user et room_max_per_team.
+// Si room_approval_required, la room est seulement demandée et créée à l'approbation d'un administrateur.
+func CreateSelfServiceRoomHandler(db *sql.DB, meetService *googleapi.MeetClient, notifier *notify.Notifier, cfgStore *config.Store, resolver *rbac.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := cfgStore.Get()
+		if !requireSelfService(c, cfg) {
+			return
+		}
+		teams, ok := userTeams(c, resolver)
+		if !ok {
+			return
+		}
+
+		room := models.Room{
+			Slug:         c.PostForm("slug"),
+			Name:         c.PostForm("name"),
+			Description:  c.PostForm("description"),
+			OwnerEmail:   c.GetString(PrincipalKey),
+			Team:         c.PostForm("team"),
+			AccessPolicy: c.PostForm("access_policy"),
+		}
+		render := selfServiceRoomForm(c, cfg, room, teams)
+		quota := roomQuota{PerUser: cfg.RoomMaxPerUser, PerTeam: cfg.RoomMaxPerTeam}
+
+		var err error
+		switch {
+		case room.Team != "" && !slices.Contains(teams, room.Team):
+			err = fieldErrors{{"team", "team must be one of your groups", "Choisissez une de vos équipes."}}
+		case room.Team == "" && len(teams) > 0 && cfg.RoomMaxPerTeam > 0:
+			err = fieldErrors{{"team", "team is required", "Choisissez l'équipe de la salle."}}
+		case cfg.RoomApprovalRequired:
+			if _, err = requestRoom(c, db, notifier, room, quota); err == nil {
+				c.Redirect(http.StatusSeeOther, "/rooms/requests")
+				return
+			}
+		default:
+			_, err = createRoom(c, db, meetService, room, quota, nil, nil)
+		}
+		if err != nil {
+			respondRoomForm(c, render, "Error creating self-service room", "La salle n'a pas pu être créée.", err, slog.String("slug", room.Slug))
+			return
+		}
+
+		slog.InfoContext(c.Request.Context(), "Self-service room created", slog.String("actor", actor(c)), slog.String("slug", room.Slug), slog.String("team", room.Team))
+		c.Redirect(http.StatusSeeOther, "/")
+	}
+}
+
+// canSelfServeRooms indique si l'utilisateur connecté peut créer des rooms en libre-service :
+// la fonctionnalité doit être activée et son adresse appartenir à un domaine Workspace autorisé.
+func canSelfServeRooms(cfg config.Config, email string) bool {
+	if !cfg.RoomSelfServiceEnabled || email == "" {
+		return false
+	}
+	domains := cfg.SignInAllowedDomains
+	if cfg.GoogleWorkspaceDomain != "" {
+		domains = append([]string{cfg.GoogleWorkspaceDomain}, domains...)
+	}
+	if len(domains) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return slices.ContainsFunc(domains, func(allowed string) bool { return strings.EqualFold(allowed, domain) })
+}
+
+func requireSelfService(c *gin.Context, cfg config.Config) bool {
+	if !canSelfServeRooms(cfg, c.GetString(PrincipalKey)) {
+		respondErrorPage(c, http.StatusForbidden, "Self-service room creation not allowed", "La création de salles en libre-service n'est pas ouverte à votre compte.", nil)
+		return false
+	}
+	return true
+}
+
+// userTeams renvoie les groupes de l'utilisateur, parmi lesquels il choisit l'équipe de la room.
+func userTeams(c *gin.Context, resolver *rbac.Resolver) ([]string, bool) {
+	teams, err := resolver.UserGroups(c.Request.Context(), c.GetString(PrincipalKey))
+	if err != nil {
+		respondErrorPage(c, http.StatusServiceUnavailable, "Unable to resolve user groups", "Vos équipes n'ont pas pu être chargées. Veuillez réessayer.", err)
+		return nil, false
+	}
+	return teams, true
+}
+
+func selfServiceRoomForm(c *gin.Context, cfg config.Config, room models.Room, teams []string) roomFormRenderer {
+	return func(status int, errs map[string]string) {
+		renderHTML(c, status, "room_form.html", gin.H{
+			"csrfToken":    csrfToken(c),
+			"formAction":   "/rooms",
+			"backURL":      "/",
+			"room":         room,
+			"errors":       errs,
+			"policies":     accessPolicyLabels,
+			"teams":        teams,
+			"teamRequired": cfg.RoomMaxPerTeam > 0,
+			"approval":     cfg.RoomApprovalRequired,
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+
+	"groom/internal/models"
+	sessionstore "groom/internal/session"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// POST /auth/logout-everywhere
+func LogoutEverywhereHandler(db *sql.DB, cookieOptions sessions.Options) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := actor(c)
+		revoked, err := models.RevokeUserSessions(c.Request.Context(), db, userEmail)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Failed to revoke user sessions", "Vos sessions n'ont pas pu être fermées. Veuillez réessayer.", err)
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "User logged out everywhere", slog.String("actor", userEmail), slog.Int64("sessions", revoked))
+
+		// La session courante, déjà révoquée, est supprimée avec son cookie
+		session := sessions.Default(c)
+		session.Clear()
+		cookieOptions.MaxAge = -1
+		session.Options(cookieOptions)
+		if err := session.Save(); err != nil {
+			logError(c, http.StatusInternalServerError, "Failed to delete session", err)
+		}
+		c.Redirect(http.StatusFound, "/")
+	}
+}
+
+// GET /api/sessions
+func ListSessionsJSONHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := c.Query("user_email")
+		activeSessions, err := models.GetActiveSessions(c.Request.Context(), db, userEmail)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Unable to retrieve sessions", err, slog.String("user_email", userEmail))
+			return
+		}
+		if activeSessions == nil {
+			activeSessions = []models.Session{}
+		}
+		c.JSON(http.StatusOK, activeSessions)
+	}
+}
+
+// DELETE /api/sessions/:id
+func RevokeSessionHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		revoked, err := models.RevokeSession(c.Request.Context(), db, id)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error revoking session", err, slog.String("session_id", id))
+			return
+		}
+		if !revoked {
+			respondError(c, http.StatusNotFound, "Session not found", nil, slog.String("session_id", id))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Session revoked", slog.String("actor", actor(c)), slog.String("session_id", id))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// DELETE /api/users/:email/sessions
+func RevokeUserSessionsHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := c.Param("email")
+		revoked, err := models.RevokeUserSessions(c.Request.Context(), db, userEmail)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, "Error revoking user sessions", err, slog.String("user_email", userEmail))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "User sessions revoked", slog.String("actor", actor(c)), slog.String("user_email", userEmail), slog.Int64("sessions", revoked))
+		c.JSON(http.StatusOK, gin.H{"revoked": revoked})
+	}
+}
+
+// GET /admin/sessions
+func ListSessionsHTMLHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		activeSessions, err := models.GetActiveSessions(c.Request.Context(), db, c.Query("user_email"))
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Unable to retrieve sessions", "La liste des sessions n'a pas pu être chargée.", err)
+			return
+		}
+
+		renderHTML(c, http.StatusOK, "admin_sessions.html", gin.H{
+			"csrfToken": csrfToken(c),
+			"sessions":  activeSessions,
+			"currentID": sessionstore.ID(sessions.Default(c).ID()),
+			"userEmail": c.Query("user_email"),
+		})
+	}
+}
+
+// POST /admin/sessions/:id/revoke
+func RevokeSessionFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if _, err := models.RevokeSession(c.Request.Context(), db, id); err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Error revoking session", "La session n'a pas pu être révoquée.", err, slog.String("session_id", id))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "Session revoked", slog.String("actor", actor(c)), slog.String("session_id", id))
+		c.Redirect(http.StatusSeeOther, "/admin/sessions")
+	}
+}
+
+// POST /admin/sessions/revoke-user
+func RevokeUserSessionsFormHandler(db *sql.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userEmail := c.PostForm("user_email")
+		if userEmail == "" {
+			respondErrorPage(c, http.StatusBadRequest, "Missing user email", "Aucun utilisateur n'a été indiqué.", nil)
+			return
+		}
+		revoked, err := models.RevokeUserSessions(c.Request.Context(), db, userEmail)
+		if err != nil {
+			respondErrorPage(c, http.StatusInternalServerError, "Error revoking user sessions", "Les sessions de l'utilisateur n'ont pas pu être révoquées.", err, slog.String("user_email", userEmail))
+			return
+		}
+		slog.InfoContext(c.Request.Context(), "User sessions revoked", slog.String("actor", actor(c)), slog.String("user_email", userEmail), slog.Int64("sessions", revoked))
+		c.Redirect(http.StatusSeeOther, "/admin/sessions")
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+
+	"groom/internal/config"
+	googleapi "groom/internal/google"
+)
+
+// signInRejection explique pourquoi un compte Google authentifié n'est pas autorisé à se connecter.
+type signInRejection struct {
+	reason  string // pour les logs
+	message string // pour la page "accès refusé"
+}
+
+// checkSignIn applique les règles de connexion : la liste de refus l'emporte, puis l'email doit être vérifié
+// (si exigé) et le compte doit figurer dans la liste d'autorisation ou appartenir à un domaine autorisé.
+// Le domaine est celui du claim "hd", présent uniquement pour les comptes gérés par Google Workspace :
+// le domaine de l'adresse email ne suffit pas, n'importe qui pouvant créer un compte Google avec une adresse quelconque.
+func checkSignIn(cfg config.Config, identity *googleapi.Identity) *signInRejection {
+	if containsFold(cfg.SignInDeniedEmails, identity.Email) {
+		return &signInRejection{"email_denied", "Ce compte n'est pas autorisé à utiliser groom."}
+	}
+	if cfg.SignInRequireEmailVerified && !identity.EmailVerified {
+		return &signInRejection{"email_not_verified", "L'adresse email de ce compte n'est pas vérifiée par Google."}
+	}
+	if containsFold(cfg.SignInAllowedEmails, identity.Email) {
+		return nil
+	}
+	if identity.Domain != "" && containsFold(allowedDomains(cfg), identity.Domain) {
+		return nil
+	}
+	return &signInRejection{"domain_not_allowed", "Ce compte n'appartient à aucune des organisations autorisées à utiliser groom."}
+}
+
+func allowedDomains(cfg config.Config) []string {
+	domains := slices.Clone(cfg.SignInAllowedDomains)
+	if cfg.GoogleWorkspaceDomain != "" {
+		domains = append(domains, cfg.GoogleWorkspaceDomain)
+	}
+	return domains
+}
+
+// signInHostedDomain renvoie le domaine suggéré à Google (paramètre "hd") lorsqu'un seul domaine est autorisé.
+func signInHostedDomain(cfg config.Config) string {
+	if domains := allowedDomains(cfg); len(domains) == 1 && len(cfg.SignInAllowedEmails) == 0 {
+		return domains[0]
+	}
+	return ""
+}
+
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(strings.TrimSpace(v), value)
+	})
+}
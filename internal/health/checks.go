@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"groom/internal/db"
+	googleapi "groom/internal/google"
+)
+
+// DatabaseCheck vérifie que Postgres répond.
+func DatabaseCheck(database *sql.DB, interval, timeout time.Duration) Check {
+	return Check{
+		Name:     "database",
+		Critical: true,
+		Interval: interval,
+		Timeout:  timeout,
+		Run:      database.PingContext,
+	}
+}
+
+// MeetCheck vérifie l'accès à l'API Google Meet. Une panne de Google ne rend pas groom "unhealthy".
+func MeetCheck(meetService *googleapi.MeetClient, interval, timeout time.Duration) Check {
+	return Check{
+		Name:     "meet",
+		Critical: false,
+		Interval: interval,
+		Timeout:  timeout,
+		Run:      meetService.CheckMeetClient,
+	}
+}
+
+// OAuthConfigCheck vérifie que la configuration OAuth utilisateur est complète.
+func OAuthConfigCheck(interval time.Duration) Check {
+	return Check{
+		Name:     "oauth_config",
+		Critical: true,
+		Interval: interval,
+		Timeout:  time.Second,
+		Run: func(ctx context.Context) error {
+			oauthConfig := googleapi.UserOAuth()
+			if oauthConfig == nil {
+				return errors.New("user OAuth config is not initialized")
+			}
+			if oauthConfig.ClientID == "" || oauthConfig.ClientSecret == "" || oauthConfig.RedirectURL == "" {
+				return errors.New("user OAuth config is incomplete")
+			}
+			return nil
+		},
+	}
+}
+
+// MigrationsCheck vérifie que le schéma est à jour et n'est pas dans un état "dirty".
+func MigrationsCheck(database *sql.DB, migrationsPath string, interval, timeout time.Duration) Check {
+	return Check{
+		Name:     "migrations",
+		Critical: true,
+		Interval: interval,
+		Timeout:  timeout,
+		Run: func(ctx context.Context) error {
+			return db.CheckMigrations(ctx, database, migrationsPath)
+		},
+	}
+}
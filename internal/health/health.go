@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Check décrit une dépendance vérifiée périodiquement en tâche de fond.
+// Une dépendance non critique en échec rend le service "degraded" sans le rendre "unhealthy".
+type Check struct {
+	Name     string
+	Critical bool
+	Interval time.Duration
+	Timeout  time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Result est le dernier résultat connu d'une vérification.
+type Result struct {
+	Name        string     `json:"name"`
+	Up          bool       `json:"up"`
+	Critical    bool       `json:"critical"`
+	LatencyMs   int64      `json:"latency_ms"`
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type Checker struct {
+	checks   []Check
+	mu       sync.RWMutex
+	results  map[string]*Result
+	draining atomic.Bool
+}
+
+func NewChecker(checks ...Check) *Checker {
+	results := make(map[string]*Result, len(checks))
+	for _, check := range checks {
+		results[check.Name] = &Result{Name: check.Name, Critical: check.Critical}
+	}
+	return &Checker{checks: checks, results: results}
+}
+
+// Run lance une goroutine par vérification et rend la main quand ctx est annulé et qu'elles sont toutes terminées.
+func (c *Checker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.loop(ctx, check)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Checker) loop(ctx context.Context, check Check) {
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.run(ctx, check)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Checker) run(ctx context.Context, check Check) {
+	checkCtx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(checkCtx)
+	latency := time.Since(start)
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := c.results[check.Name]
+	result.Up = err == nil
+	result.LatencyMs = latency.Milliseconds()
+	result.CheckedAt = &now
+	if err != nil {
+		result.LastError = err.Error()
+		result.LastErrorAt = &now
+		slog.WarnContext(ctx, "Health check failed", slog.String("check", check.Name), slog.Duration("latency", latency), slog.Any("error", err))
+	}
+}
+
+// Results renvoie une copie des derniers résultats, dans l'ordre de déclaration des vérifications.
+func (c *Checker) Results() []Result {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make([]Result, 0, len(c.checks))
+	for _, check := range c.checks {
+		results = append(results, *c.results[check.Name])
+	}
+	return results
+}
+
+// Drain signale un arrêt en cours : le service n'est plus prêt à recevoir du trafic.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready indique si le service peut recevoir du trafic : pas d'arrêt en cours et aucune dépendance critique en échec.
+func (c *Checker) Ready() bool {
+	return !c.draining.Load() && c.Status() != StatusUnhealthy
+}
+
+// Status agrège les résultats : une vérification qui n'a pas encore tourné compte comme un échec.
+func (c *Checker) Status() Status {
+	status := StatusHealthy
+	for _, result := range c.Results() {
+		if result.Up {
+			continue
+		}
+		if result.Critical {
+			return StatusUnhealthy
+		}
+		status = StatusDegraded
+	}
+	return status
+}
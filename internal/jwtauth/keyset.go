@@ -0,0 +1,150 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Délai minimal entre deux rechargements déclenchés par un identifiant de clé inconnu (rotation chez l'émetteur)
+const minRefreshInterval = time.Minute
+
+// KeySet est le jeu de clés publiques (JWKS) servant à vérifier les tokens, lu depuis une URL ou un fichier.
+// Il est conservé en mémoire et rechargé périodiquement par Run ; en cas d'échec, les clés précédentes restent utilisées.
+type KeySet struct {
+	url      string
+	file     string
+	interval time.Duration
+	client   *http.Client
+
+	mu          sync.RWMutex
+	keys        jose.JSONWebKeySet
+	refreshedAt time.Time
+}
+
+// NewKeySet charge le jeu de clés une première fois.
+func NewKeySet(ctx context.Context, url, file string, interval time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		url:      url,
+		file:     file,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+	}
+	if err := ks.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Refresh relit le jeu de clés ; seules les parties publiques des clés sont conservées.
+func (ks *KeySet) Refresh(ctx context.Context) error {
+	data, err := ks.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	var raw jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	var keys jose.JSONWebKeySet
+	for _, key := range raw.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		keys.Keys = append(keys.Keys, key.Public())
+	}
+	if len(keys.Keys) == 0 {
+		return errors.New("JWKS contains no signing key")
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.refreshedAt = time.Now()
+	ks.mu.Unlock()
+	return nil
+}
+
+func (ks *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if ks.file != "" {
+		return os.ReadFile(ks.file)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch JWKS: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// Key renvoie la clé d'identifiant kid. Une clé inconnue déclenche un rechargement, au plus une fois par minute.
+// Sans kid, la clé n'est trouvée que si le jeu n'en contient qu'une.
+func (ks *KeySet) Key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	if key := ks.lookup(kid); key != nil {
+		return key, nil
+	}
+
+	ks.mu.RLock()
+	stale := time.Since(ks.refreshedAt) > minRefreshInterval
+	ks.mu.RUnlock()
+	if stale {
+		if err := ks.Refresh(ctx); err != nil {
+			slog.WarnContext(ctx, "Failed to refresh JWKS", slog.Any("error", err))
+		} else if key := ks.lookup(kid); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (ks *KeySet) lookup(kid string) *jose.JSONWebKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid == "" {
+		if len(ks.keys.Keys) == 1 {
+			return &ks.keys.Keys[0]
+		}
+		return nil
+	}
+	if keys := ks.keys.Key(kid); len(keys) > 0 {
+		return &keys[0]
+	}
+	return nil
+}
+
+// Run recharge le jeu de clés à intervalle régulier, jusqu'à l'annulation de ctx.
+func (ks *KeySet) Run(ctx context.Context) {
+	ticker := time.NewTicker(ks.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Refresh(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to refresh JWKS, keeping the previous keys", slog.Any("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package jwtauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// GenerateKeySet crée une clé ECDSA P-256 et renvoie le jeu privé (pour signer) et le jeu public (pour jwt_jwks_file).
+// Destiné aux environnements de développement et de test.
+func GenerateKeySet() (private, public jose.JSONWebKeySet, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return private, public, err
+	}
+
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return private, public, err
+	}
+
+	jwk := jose.JSONWebKey{Key: key, KeyID: hex.EncodeToString(kid), Algorithm: string(jose.ES256), Use: "sig"}
+	private.Keys = []jose.JSONWebKey{jwk}
+	public.Keys = []jose.JSONWebKey{jwk.Public()}
+	return private, public, nil
+}
+
+// Sign émet un token signé par la première clé du jeu privé.
+func Sign(private jose.JSONWebKeySet, issuer, subject string, audiences, scopes []string, ttl time.Duration) (string, error) {
+	if len(private.Keys) == 0 || private.Keys[0].IsPublic() {
+		return "", errors.New("the key set contains no private key")
+	}
+	key := private.Keys[0]
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.SignatureAlgorithm(key.Algorithm), Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Audience:  audiences,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Expiry:    jwt.NewNumericDate(now.Add(ttl)),
+	}
+	return jwt.Signed(signer).Claims(claims).Claims(map[string]any{"scope": strings.Join(scopes, " ")}).Serialize()
+}
@@ -0,0 +1,169 @@
+package jwtauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"groom/internal/apikey"
+	"groom/internal/config"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Algorithmes de signature asymétriques acceptés ; "none" et HMAC sont refusés
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// Tolérance sur exp, nbf et iat pour les décalages d'horloge
+const leeway = time.Minute
+
+// Identity est le service authentifié par un token.
+type Identity struct {
+	Subject string
+	Issuer  string
+	Scopes  []string
+}
+
+// Principal identifie le service dans groom, pour la propriété des rooms et le journal d'audit. Le sujet est qualifié
+// par l'émetteur : deux émetteurs de confiance peuvent attribuer le même sujet à des services différents.
+// L'émetteur OIDC est une URL sans fragment, ce qui rend le "#" non ambigu.
+func (i *Identity) Principal() string {
+	return i.Issuer + "#" + i.Subject
+}
+
+// Verifier vérifie les tokens JWT présentés en "Authorization: Bearer".
+type Verifier struct {
+	Keys *KeySet
+
+	issuers      []string
+	audiences    []string
+	scopeClaim   string
+	scopeMapping map[string][]string
+}
+
+// NewVerifier renvoie nil si aucun JWKS n'est configuré.
+func NewVerifier(ctx context.Context, cfg config.Config) (*Verifier, error) {
+	if cfg.JWTJWKSURL == "" && cfg.JWTJWKSFile == "" {
+		return nil, nil
+	}
+
+	scopeMapping, err := ParseScopeMapping(cfg.JWTScopeMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := NewKeySet(ctx, cfg.JWTJWKSURL, cfg.JWTJWKSFile, cfg.JWTJWKSRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		Keys:         keys,
+		issuers:      cfg.JWTIssuers,
+		audiences:    cfg.JWTAudiences,
+		scopeClaim:   cfg.JWTScopeClaim,
+		scopeMapping: scopeMapping,
+	}, nil
+}
+
+// ParseScopeMapping lit les associations "valeur=scope" : la valeur du claim de scopes donne le scope groom.
+// Une même valeur peut apparaître plusieurs fois pour accorder plusieurs scopes.
+func ParseScopeMapping(entries []string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+	for _, entry := range entries {
+		value, scope, ok := strings.Cut(entry, "=")
+		value, scope = strings.TrimSpace(value), strings.TrimSpace(scope)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid jwt_scope_mapping entry %q (expected value=scope)", entry)
+		}
+		if err := apikey.ValidateScopes([]string{scope}); err != nil {
+			return nil, fmt.Errorf("jwt_scope_mapping entry %q: %w", entry, err)
+		}
+		mapping[value] = append(mapping[value], scope)
+	}
+	return mapping, nil
+}
+
+// Verify contrôle la signature, l'émetteur, l'audience et les dates du token, puis en déduit les scopes groom.
+func (v *Verifier) Verify(ctx context.Context, rawToken string) (*Identity, error) {
+	token, err := jwt.ParseSigned(rawToken, signatureAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+	if len(token.Headers) != 1 {
+		return nil, errors.New("token must have exactly one signature")
+	}
+
+	key, err := v.Keys.Key(ctx, token.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims jwt.Claims
+	var extra map[string]any
+	if err := token.Claims(key, &claims, &extra); err != nil {
+		return nil, err
+	}
+
+	if claims.Expiry == nil {
+		return nil, errors.New("token has no expiry")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	if !slices.Contains(v.issuers, claims.Issuer) {
+		return nil, fmt.Errorf("untrusted issuer %q", claims.Issuer)
+	}
+	err = claims.ValidateWithLeeway(jwt.Expected{
+		AnyAudience: v.audiences,
+		Time:        time.Now(),
+	}, leeway)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		Subject: claims.Subject,
+		Issuer:  claims.Issuer,
+		Scopes:  v.scopes(extra[v.scopeClaim]),
+	}, nil
+}
+
+// scopes lit le claim de scopes (chaîne séparée par des espaces, comme "scope" en OAuth 2, ou liste)
+// et le traduit par le mapping ; sans mapping, seuls les scopes groom sont retenus.
+func (v *Verifier) scopes(claim any) []string {
+	var values []string
+	switch claim := claim.(type) {
+	case string:
+		values = strings.Fields(claim)
+	case []any:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var scopes []string
+	for _, value := range values {
+		granted := v.scopeMapping[value]
+		if len(v.scopeMapping) == 0 && slices.Contains(apikey.Scopes, value) {
+			granted = []string{value}
+		}
+		for _, scope := range granted {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,186 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"groom/internal/config"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const (
+	issuer   = "https://idp.example.test"
+	audience = "groom"
+)
+
+// newVerifier renvoie un vérificateur lisant le jeu public dans un fichier JWKS, et le jeu privé qui signe.
+func newVerifier(t *testing.T, scopeMapping ...string) (*Verifier, jose.JSONWebKeySet) {
+	t.Helper()
+	private, public, err := GenerateKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := NewVerifier(context.Background(), config.Config{
+		JWTJWKSFile:            file,
+		JWTJWKSRefreshInterval: time.Hour,
+		JWTIssuers:             []string{issuer},
+		JWTAudiences:           []string{audience},
+		JWTScopeClaim:          "scope",
+		JWTScopeMapping:        scopeMapping,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return verifier, private
+}
+
+func sign(t *testing.T, private jose.JSONWebKeySet, iss string, aud []string, scopes []string, ttl time.Duration) string {
+	t.Helper()
+	token, err := Sign(private, iss, "svc-calendar", aud, scopes, ttl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// signClaims signe des claims quelconques avec la clé donnée, pour les tokens que Sign ne produit pas.
+func signClaims(t *testing.T, alg jose.SignatureAlgorithm, key any, claims any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	verifier, private := newVerifier(t)
+	otherPrivate, _, err := GenerateKeySet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Même identifiant de clé que la clé de confiance, mais une autre clé privée
+	forged := otherPrivate.Keys[0]
+	forged.KeyID = private.Keys[0].KeyID
+
+	valid := sign(t, private, issuer, []string{audience}, []string{"rooms:read"}, time.Hour)
+	// Jeton compact : en-tête.claims.signature
+	parts := strings.Split(valid, ".")
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(claims), "svc-calendar", "svc-admin", 1))) + "." + parts[2]
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string // vide : le token doit être accepté
+	}{
+		{"valid", valid, ""},
+		{"tampered payload", tampered, "error in cryptographic primitive"},
+		{"bad signature", signClaims(t, jose.ES256, forged, jwt.Claims{
+			Issuer: issuer, Audience: jwt.Audience{audience}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}), "error in cryptographic primitive"},
+		{"wrong issuer", sign(t, private, "https://evil.example.test", []string{audience}, nil, time.Hour), "untrusted issuer"},
+		{"wrong audience", sign(t, private, issuer, []string{"other"}, nil, time.Hour), "invalid audience"},
+		{"expired", sign(t, private, issuer, []string{audience}, nil, -2*leeway), "token is expired"},
+		{"expired within leeway", sign(t, private, issuer, []string{audience}, nil, -leeway/2), ""},
+		{"missing exp", signClaims(t, jose.ES256, private.Keys[0], jwt.Claims{
+			Issuer: issuer, Subject: "svc-calendar", Audience: jwt.Audience{audience},
+		}), "token has no expiry"},
+		{"missing sub", signClaims(t, jose.ES256, private.Keys[0], jwt.Claims{
+			Issuer: issuer, Audience: jwt.Audience{audience}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}), "token has no subject"},
+		{"alg none", unsigned, `unexpected signature algorithm "none"`},
+		{"alg HS256", signClaims(t, jose.HS256, jose.JSONWebKey{Key: []byte(strings.Repeat("k", 32)), KeyID: private.Keys[0].KeyID}, jwt.Claims{
+			Issuer: issuer, Audience: jwt.Audience{audience}, Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}), `unexpected signature algorithm "HS256"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := verifier.Verify(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Verify() = %+v, %v, want an error containing %q", identity, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if identity.Subject != "svc-calendar" || identity.Issuer != issuer || identity.Principal() != issuer+"#svc-calendar" {
+				t.Fatalf("Verify() = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestVerifyScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping []string
+		claim   any
+		want    []string
+	}{
+		{"groom scopes without mapping", nil, "rooms:read calendar.read admin", []string{"rooms:read", "admin"}},
+		{"list claim", nil, []string{"rooms:write"}, []string{"rooms:write"}},
+		{"mapped values", []string{"meet.read=rooms:read", "meet.manage=rooms:read", "meet.manage=rooms:write"},
+			"meet.manage meet.read", []string{"rooms:read", "rooms:write"}},
+		{"unmapped groom scope ignored", []string{"meet.read=rooms:read"}, "admin meet.read", []string{"rooms:read"}},
+		{"no scope", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, private := newVerifier(t, tt.mapping...)
+			claims := map[string]any{
+				"iss": issuer, "sub": "svc-calendar", "aud": audience,
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}
+			if tt.claim != nil {
+				claims["scope"] = tt.claim
+			}
+
+			identity, err := verifier.Verify(context.Background(), signClaims(t, jose.ES256, private.Keys[0], claims))
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if !slices.Equal(identity.Scopes, tt.want) {
+				t.Fatalf("scopes = %v, want %v", identity.Scopes, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScopeMapping(t *testing.T) {
+	for _, entry := range []string{"rooms:read", "=rooms:read", "meet.read=rooms:delete"} {
+		if _, err := ParseScopeMapping([]string{entry}); err == nil {
+			t.Errorf("ParseScopeMapping(%q) succeeded, want an error", entry)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Component est un élément démarré puis arrêté par le Manager.
+// Start ne doit pas bloquer : les traitements longs tournent dans leurs propres goroutines.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager démarre les composants dans l'ordre d'ajout et les arrête dans l'ordre inverse.
+type Manager struct {
+	mu         sync.Mutex
+	components []Component
+	started    []Component
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+func (m *Manager) Add(components ...Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, components...)
+}
+
+// Start démarre chaque composant ; en cas d'échec, ceux déjà démarrés sont arrêtés.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	components := m.components[len(m.started):]
+	m.mu.Unlock()
+
+	for _, component := range components {
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				err = fmt.Errorf("starting %s: %w", component.Name, err)
+				return errors.Join(err, m.Stop(ctx))
+			}
+		}
+		slog.InfoContext(ctx, "Component started", slog.String("component", component.Name))
+
+		m.mu.Lock()
+		m.started = append(m.started, component)
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop arrête les composants démarrés, du dernier au premier, et agrège les erreurs.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	started := m.started
+	m.started = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		component := started[i]
+		if component.Stop != nil {
+			if err := component.Stop(ctx); err != nil {
+				slog.ErrorContext(ctx, "Component failed to stop", slog.String("component", component.Name), slog.Any("error", err))
+				errs = append(errs, fmt.Errorf("stopping %s: %w", component.Name, err))
+				continue
+			}
+		}
+		slog.InfoContext(ctx, "Component stopped", slog.String("component", component.Name))
+	}
+	return errors.Join(errs...)
+}
+
+// Worker transforme une boucle de fond (poller, rafraîchissement de cache, file de tâches) en Component.
+// run doit rendre la main quand son contexte est annulé ; Stop attend sa fin ou l'expiration du contexte d'arrêt.
+func Worker(name string, run func(ctx context.Context)) Component {
+	var cancel context.CancelFunc
+	done := make(chan struct{})
+
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+var requestIDKey = contextKey{}
+
+var level slog.LevelVar
+
+// Setup installe un logger JSON comme logger par défaut (slog et package log).
+func Setup(logLevel string) {
+	SetLevel(logLevel)
+
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: &level})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// SetLevel change le niveau de log à chaud ; un niveau inconnu équivaut à "info".
+func SetLevel(logLevel string) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.ToUpper(logLevel))); err != nil {
+		lvl = slog.LevelInfo
+	}
+	level.Set(lvl)
+}
+
+// WithRequestID attache un identifiant de requête au contexte.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID renvoie l'identifiant de requête porté par le contexte.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// contextHandler enrichit chaque enregistrement avec l'identifiant de requête et la trace courante.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// APIKey est une clé d'accès à l'API. Seule l'empreinte du secret est conservée ;
+// le préfixe, public, permet de retrouver la clé présentée.
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Owner      string     `json:"owner"`
+	Prefix     string     `json:"prefix"`
+	SecretHash string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+const apiKeyColumns = "id, name, owner, prefix, secret_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*APIKey, error) {
+	key := &APIKey{}
+	var scopes string
+	err := row.Scan(&key.ID, &key.Name, &key.Owner, &key.Prefix, &key.SecretHash, &scopes,
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, ",")
+	}
+	return key, nil
+}
+
+// GetAPIKeyByPrefix renvoie la clé correspondant au préfixe, révoquée ou expirée comprise, ou nil.
+func GetAPIKeyByPrefix(ctx context.Context, db *sql.DB, prefix string) (key *APIKey, err error) {
+	ctx, end := startSpan(ctx, "GetAPIKeyByPrefix", attribute.String("api_key.prefix", prefix))
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix)
+
+	key, err = scanAPIKey(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+func GetAllAPIKeys(ctx context.Context, db *sql.DB) (keys []APIKey, err error) {
+	ctx, end := startSpan(ctx, "GetAllAPIKeys")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+func CreateAPIKey(ctx context.Context, db *sql.DB, key *APIKey) (err error) {
+	ctx, end := startSpan(ctx, "CreateAPIKey", attribute.String("api_key.name", key.Name))
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO api_keys (name, owner, prefix, secret_hash, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+	return db.QueryRowContext(ctx, query, key.Name, key.Owner, key.Prefix, key.SecretHash,
+		strings.Join(key.Scopes, ","), time.Now(), key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+}
+
+// RevokeAPIKey révoque une clé ; elle renvoie false si la clé n'existe pas ou est déjà révoquée.
+func RevokeAPIKey(ctx context.Context, db *sql.DB, id int) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "RevokeAPIKey", attribute.Int("api_key.id", id))
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked > 0, err
+}
+
+// TouchAPIKey met à jour la date de dernière utilisation, au plus une fois par minute.
+func TouchAPIKey(ctx context.Context, db *sql.DB, id int) (err error) {
+	ctx, end := startSpan(ctx, "TouchAPIKey", attribute.Int("api_key.id", id))
+	defer func() { end(err) }()
+
+	now := time.Now()
+	_, err = db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = $1
+		WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)`, now, id, now.Add(-time.Minute))
+	return err
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Actions enregistrées dans le journal d'audit
+const (
+	AuditRoomCreate      = "room.create"
+	AuditRoomUpdate      = "room.update"
+	AuditRoomDelete      = "room.delete"
+	AuditRoomAccess      = "room.access"
+	AuditRoomRotateSpace = "room.rotate_space"
+	AuditRoomArchive     = "room.archive"
+	AuditRoomUnarchive   = "room.unarchive"
+	AuditRoomRevert      = "room.revert"
+	AuditGuestLinkCreate = "guest_link.create"
+	AuditGuestLinkRevoke = "guest_link.revoke"
+	AuditGuestLinkUse    = "guest_link.use"
+
+	// Les événements des salles personnelles n'ont pas de room_id : leur état est une PersonalRoom
+	AuditPersonalRoomCreate   = "personal_room.create"
+	AuditPersonalRoomReassign = "personal_room.reassign"
+	AuditPersonalRoomDelete   = "personal_room.delete"
+	AuditPersonalRoomCleanup  = "personal_room.cleanup"
+)
+
+// AuditEvent est une modification enregistrée dans la table audit_events, en ajout seul.
+// Before et After contiennent l'état de l'objet (RoomState pour une room) avant et après la modification.
+type AuditEvent struct {
+	ID              int64           `json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	Actor           string          `json:"actor"`
+	Action          string          `json:"action"`
+	RoomID          *int            `json:"room_id,omitempty"`
+	Before          json.RawMessage `json:"before,omitempty"`
+	After           json.RawMessage `json:"after,omitempty"`
+	RevertedEventID *int64          `json:"reverted_event_id,omitempty"`
+	IP              string          `json:"ip"`
+	RequestID       string          `json:"request_id"`
+}
+
+// RoomState est l'état complet d'une room tel qu'enregistré dans le journal d'audit, autorisations comprises.
+type RoomState struct {
+	Room
+	Access []RoomAccess `json:"access"`
+}
+
+// AuditFilter restreint la liste des événements ; les champs vides sont ignorés.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	RoomID   int
+	Since    time.Time
+	Until    time.Time
+	BeforeID int64 // pagination : événements antérieurs à cet identifiant
+	Limit    int
+}
+
+const auditEventColumns = "id, created_at, actor, action, room_id, before, after, reverted_event_id, ip, request_id"
+
+func scanAuditEvent(row interface{ Scan(...any) error }) (*AuditEvent, error) {
+	event := &AuditEvent{}
+	var roomID sql.NullInt32
+	var before, after []byte
+	err := row.Scan(&event.ID, &event.CreatedAt, &event.Actor, &event.Action, &roomID, &before, &after,
+		&event.RevertedEventID, &event.IP, &event.RequestID)
+	if err != nil {
+		return nil, err
+	}
+	if roomID.Valid {
+		id := int(roomID.Int32)
+		event.RoomID = &id
+	}
+	event.Before, event.After = before, after
+	return event, nil
+}
+
+// GetRoomState renvoie l'état de la room et ses autorisations, ou nil si elle n'existe pas.
+func GetRoomState(ctx context.Context, db DBTX, id int) (*RoomState, error) {
+	room, err := GetRoomByID(ctx, db, id)
+	if err != nil || room == nil {
+		return nil, err
+	}
+	return roomState(ctx, db, room)
+}
+
+// LockRoomState renvoie l'état de la room comme GetRoomState, en verrouillant la room jusqu'à la fin de la transaction :
+// une modification concurrente attend celle-ci, et l'état « avant » journalisé est bien celui qu'elle remplace.
+func LockRoomState(ctx context.Context, tx *sql.Tx, id int) (*RoomState, error) {
+	room, err := GetRoomByIDForUpdate(ctx, tx, id)
+	if err != nil || room == nil {
+		return nil, err
+	}
+	return roomState(ctx, tx, room)
+}
+
+func roomState(ctx context.Context, db DBTX, room *Room) (*RoomState, error) {
+	entries, err := GetRoomAccess(ctx, db, room.ID)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []RoomAccess{}
+	}
+	return &RoomState{Room: *room, Access: entries}, nil
+}
+
+// InsertAuditEvent enregistre l'événement, dans la transaction de la modification qu'il décrit.
+func InsertAuditEvent(ctx context.Context, db DBTX, event *AuditEvent) (err error) {
+	ctx, end := startSpan(ctx, "InsertAuditEvent", attribute.String("audit.action", event.Action))
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO audit_events (created_at, actor, action, room_id, before, after, reverted_event_id, ip, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at`
+	return db.QueryRowContext(ctx, query, time.Now(), event.Actor, event.Action, event.RoomID,
+		nullJSON(event.Before), nullJSON(event.After), event.RevertedEventID, event.IP, event.RequestID).
+		Scan(&event.ID, &event.CreatedAt)
+}
+
+func GetAuditEventByID(ctx context.Context, db DBTX, id int64) (event *AuditEvent, err error) {
+	ctx, end := startSpan(ctx, "GetAuditEventByID", attribute.Int64("audit.id", id))
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, "SELECT "+auditEventColumns+" FROM audit_events WHERE id = $1", id)
+
+	event, err = scanAuditEvent(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetAuditEvents renvoie les événements correspondant au filtre, du plus récent au plus ancien.
+func GetAuditEvents(ctx context.Context, db *sql.DB, filter AuditFilter) (events []AuditEvent, err error) {
+	ctx, end := startSpan(ctx, "GetAuditEvents")
+	defer func() { end(err) }()
+
+	var conditions []string
+	var args []any
+	where := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, strings.ReplaceAll(condition, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if filter.Actor != "" {
+		where("actor = ?", filter.Actor)
+	}
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+	if filter.RoomID != 0 {
+		where("room_id = ?", filter.RoomID)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < ?", filter.Until)
+	}
+	if filter.BeforeID != 0 {
+		where("id < ?", filter.BeforeID)
+	}
+
+	query := "SELECT " + auditEventColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += " ORDER BY id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	return events, rows.Err()
+}
+
+// nullJSON enregistre NULL plutôt qu'un document vide.
+func nullJSON(value json.RawMessage) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GuestLink donne accès à une room sans compte, jusqu'à son expiration ou son nombre maximal d'utilisations.
+// Le lien lui-même est signé (voir le paquet guestlink) ; seul son état est conservé en base.
+type GuestLink struct {
+	ID         int        `json:"id"`
+	RoomID     int        `json:"room_id"`
+	Label      string     `json:"label"`
+	CreatedBy  string     `json:"created_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	MaxUses    *int       `json:"max_uses,omitempty"` // nil : utilisations illimitées jusqu'à l'expiration
+	Uses       int        `json:"uses"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Usable indique si le lien peut encore ouvrir la room : ni révoqué, ni expiré, ni épuisé.
+func (l GuestLink) Usable(roomID int, now time.Time) bool {
+	return l.RoomID == roomID && l.RevokedAt == nil && l.ExpiresAt.After(now) && (l.MaxUses == nil || l.Uses < *l.MaxUses)
+}
+
+const guestLinkColumns = "id, room_id, label, created_by, created_at, expires_at, max_uses, uses, last_used_at, revoked_at"
+
+func scanGuestLink(row interface{ Scan(...any) error }) (*GuestLink, error) {
+	link := &GuestLink{}
+	var maxUses sql.NullInt32
+	err := row.Scan(&link.ID, &link.RoomID, &link.Label, &link.CreatedBy, &link.CreatedAt, &link.ExpiresAt,
+		&maxUses, &link.Uses, &link.LastUsedAt, &link.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if maxUses.Valid {
+		value := int(maxUses.Int32)
+		link.MaxUses = &value
+	}
+	return link, nil
+}
+
+func GetGuestLinkByID(ctx context.Context, db *sql.DB, id int) (link *GuestLink, err error) {
+	ctx, end := startSpan(ctx, "GetGuestLinkByID", attribute.Int("guest_link.id", id))
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, "SELECT "+guestLinkColumns+" FROM guest_links WHERE id = $1", id)
+
+	link, err = scanGuestLink(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func GetRoomGuestLinks(ctx context.Context, db *sql.DB, roomID int) (links []GuestLink, err error) {
+	ctx, end := startSpan(ctx, "GetRoomGuestLinks", attribute.Int("room.id", roomID))
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT "+guestLinkColumns+" FROM guest_links WHERE room_id = $1 ORDER BY created_at DESC", roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		link, err := scanGuestLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+	return links, rows.Err()
+}
+
+func CreateGuestLink(ctx context.Context, db DBTX, link *GuestLink) (err error) {
+	ctx, end := startSpan(ctx, "CreateGuestLink", attribute.Int("room.id", link.RoomID))
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO guest_links (room_id, label, created_by, created_at, expires_at, max_uses)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	return db.QueryRowContext(ctx, query, link.RoomID, link.Label, link.CreatedBy, time.Now(), link.ExpiresAt, link.MaxUses).
+		Scan(&link.ID, &link.CreatedAt)
+}
+
+// UseGuestLink consomme une utilisation du lien s'il est encore valable pour la room : ni révoqué, ni expiré,
+// ni épuisé. La vérification et l'incrément sont atomiques ; elle renvoie nil si le lien n'est pas utilisable.
+func UseGuestLink(ctx context.Context, db DBTX, id, roomID int) (link *GuestLink, err error) {
+	ctx, end := startSpan(ctx, "UseGuestLink", attribute.Int("guest_link.id", id), attribute.Int("room.id", roomID))
+	defer func() { end(err) }()
+
+	now := time.Now()
+	row := db.QueryRowContext(ctx, `
+		UPDATE guest_links SET uses = uses + 1, last_used_at = $1
+		WHERE id = $2 AND room_id = $3 AND revoked_at IS NULL AND expires_at > $1
+			AND (max_uses IS NULL OR uses < max_uses)
+		RETURNING `+guestLinkColumns, now, id, roomID)
+
+	link, err = scanGuestLink(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+// RevokeGuestLink révoque un lien ; elle renvoie false s'il n'existe pas ou est déjà révoqué.
+func RevokeGuestLink(ctx context.Context, db DBTX, id int) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "RevokeGuestLink", attribute.Int("guest_link.id", id))
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "UPDATE guest_links SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked > 0, err
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"groom/internal/telemetry"
+
+	"github.com/jackc/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("groom/internal/models")
+
+// DBTX est satisfaite par *sql.DB et *sql.Tx : les fonctions qui l'acceptent peuvent s'exécuter
+// dans la transaction d'une modification et de son événement d'audit.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// WithTx exécute fn dans une transaction, validée si fn réussit et annulée sinon.
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ErrSlugTaken est renvoyée par les mises à jour dont le slug vient d'être pris par une écriture concurrente.
+var ErrSlugTaken = errors.New("slug already taken")
+
+// isUniqueViolation indique si err est la violation de l'index unique nommé.
+func isUniqueViolation(err error, index string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == index
+}
+
+var queryTimeout atomic.Int64
+
+// SetQueryTimeout fixe la durée maximale de chaque requête SQL.
+func SetQueryTimeout(timeout time.Duration) {
+	queryTimeout.Store(int64(timeout))
+}
+
+func init() {
+	SetQueryTimeout(5 * time.Second)
+}
+
+// startSpan ouvre un span client pour une requête SQL et borne sa durée par queryTimeout.
+// La fonction d'annulation retournée termine le span et libère le contexte.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(error)) {
+	attrs = append(attrs, semconv.DBSystemPostgreSQL)
+	ctx, span := tracer.Start(ctx, "models."+name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(queryTimeout.Load()))
+	return ctx, func(err error) {
+		cancel()
+		telemetry.EndSpan(span, err)
+	}
+}
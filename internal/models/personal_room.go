@@ -0,0 +1,163 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PersonalRoom est la salle permanente d'un utilisateur, ouverte par /me et publique à /u/<slug>.
+type PersonalRoom struct {
+	ID         int        `json:"id"`
+	OwnerEmail string     `json:"owner_email"`
+	Slug       string     `json:"slug"`
+	SpaceID    string     `json:"space_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+const personalRoomColumns = "id, owner_email, slug, space_id, created_at, updated_at, last_used_at"
+
+func scanPersonalRoom(row interface{ Scan(...any) error }) (*PersonalRoom, error) {
+	room := &PersonalRoom{}
+	err := row.Scan(&room.ID, &room.OwnerEmail, &room.Slug, &room.SpaceID, &room.CreatedAt, &room.UpdatedAt, &room.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// queryPersonalRoom renvoie la salle trouvée par la requête, ou nil.
+func queryPersonalRoom(row *sql.Row) (*PersonalRoom, error) {
+	room, err := scanPersonalRoom(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+func GetPersonalRoomByID(ctx context.Context, db DBTX, id int) (room *PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "GetPersonalRoomByID", attribute.Int("personal_room.id", id))
+	defer func() { end(err) }()
+
+	return queryPersonalRoom(db.QueryRowContext(ctx, "SELECT "+personalRoomColumns+" FROM personal_rooms WHERE id = $1", id))
+}
+
+func GetPersonalRoomByEmail(ctx context.Context, db DBTX, ownerEmail string) (room *PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "GetPersonalRoomByEmail")
+	defer func() { end(err) }()
+
+	return queryPersonalRoom(db.QueryRowContext(ctx, "SELECT "+personalRoomColumns+" FROM personal_rooms WHERE LOWER(owner_email) = LOWER($1)", ownerEmail))
+}
+
+func GetPersonalRoomBySlug(ctx context.Context, db DBTX, slug string) (room *PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "GetPersonalRoomBySlug", attribute.String("personal_room.slug", slug))
+	defer func() { end(err) }()
+
+	return queryPersonalRoom(db.QueryRowContext(ctx, "SELECT "+personalRoomColumns+" FROM personal_rooms WHERE slug = $1", slug))
+}
+
+// GetPersonalRooms renvoie toutes les salles personnelles, par slug.
+func GetPersonalRooms(ctx context.Context, db *sql.DB) (rooms []PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "GetPersonalRooms")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT "+personalRoomColumns+" FROM personal_rooms ORDER BY slug ASC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		room, err := scanPersonalRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, *room)
+	}
+	return rooms, rows.Err()
+}
+
+// LockPersonalRoom sérialise, jusqu'à la fin de la transaction, l'attribution d'une salle personnelle à l'utilisateur :
+// une visite simultanée de /me, ou une réattribution, attend la première et trouve sa salle, sans créer un second espace Meet.
+func LockPersonalRoom(ctx context.Context, tx *sql.Tx, ownerEmail string) (err error) {
+	ctx, end := startSpan(ctx, "LockPersonalRoom")
+	defer func() { end(err) }()
+
+	_, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "personal_room:"+strings.ToLower(ownerEmail))
+	return err
+}
+
+// CreatePersonalRoom enregistre la salle ; elle renvoie nil si le slug est pris.
+// L'unicité par utilisateur est garantie par LockPersonalRoom, pris par l'appelant.
+func CreatePersonalRoom(ctx context.Context, db DBTX, room PersonalRoom) (_ *PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "CreatePersonalRoom", attribute.String("personal_room.slug", room.Slug))
+	defer func() { end(err) }()
+
+	now := time.Now()
+	query := `
+		INSERT INTO personal_rooms (owner_email, slug, space_id, created_at, updated_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $4, $4)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING ` + personalRoomColumns
+	return queryPersonalRoom(db.QueryRowContext(ctx, query, room.OwnerEmail, room.Slug, room.SpaceID, now))
+}
+
+// UpdatePersonalRoom enregistre le propriétaire et le slug de la salle.
+// Elle renvoie ErrSlugTaken si une autre salle personnelle a pris le slug depuis sa vérification.
+func UpdatePersonalRoom(ctx context.Context, db DBTX, room PersonalRoom) (err error) {
+	ctx, end := startSpan(ctx, "UpdatePersonalRoom", attribute.Int("personal_room.id", room.ID))
+	defer func() { end(err) }()
+
+	_, err = db.ExecContext(ctx, "UPDATE personal_rooms SET owner_email = $1, slug = $2, updated_at = $3 WHERE id = $4",
+		room.OwnerEmail, room.Slug, time.Now(), room.ID)
+	if isUniqueViolation(err, "personal_rooms_slug_idx") {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+// TouchPersonalRoom enregistre la dernière utilisation de la salle, qui la protège du nettoyage.
+func TouchPersonalRoom(ctx context.Context, db DBTX, id int) (err error) {
+	ctx, end := startSpan(ctx, "TouchPersonalRoom", attribute.Int("personal_room.id", id))
+	defer func() { end(err) }()
+
+	_, err = db.ExecContext(ctx, "UPDATE personal_rooms SET last_used_at = $1 WHERE id = $2", time.Now(), id)
+	return err
+}
+
+func DeletePersonalRoom(ctx context.Context, db DBTX, id int) (err error) {
+	ctx, end := startSpan(ctx, "DeletePersonalRoom", attribute.Int("personal_room.id", id))
+	defer func() { end(err) }()
+
+	_, err = db.ExecContext(ctx, "DELETE FROM personal_rooms WHERE id = $1", id)
+	return err
+}
+
+// DeleteUnusedPersonalRooms supprime les salles personnelles inutilisées depuis before et les renvoie.
+func DeleteUnusedPersonalRooms(ctx context.Context, db DBTX, before time.Time) (deleted []PersonalRoom, err error) {
+	ctx, end := startSpan(ctx, "DeleteUnusedPersonalRooms")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "DELETE FROM personal_rooms WHERE COALESCE(last_used_at, created_at) < $1 RETURNING "+personalRoomColumns, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		room, err := scanPersonalRoom(rows)
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, *room)
+	}
+	return deleted, rows.Err()
+}
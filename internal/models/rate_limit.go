@@ -0,0 +1,32 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// IncrementRateLimitCounter compte une requête de plus pour la clé dans la fenêtre commençant à windowStart
+// et renvoie le total de la fenêtre.
+func IncrementRateLimitCounter(ctx context.Context, db *sql.DB, key string, windowStart, expiresAt time.Time) (count int, err error) {
+	ctx, end := startSpan(ctx, "IncrementRateLimitCounter")
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO rate_limits (key, window_start, count, expires_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (key, window_start) DO UPDATE SET count = rate_limits.count + 1
+		RETURNING count`, key, windowStart, expiresAt).Scan(&count)
+	return count, err
+}
+
+// DeleteExpiredRateLimitCounters supprime les compteurs des fenêtres terminées.
+func DeleteExpiredRateLimitCounters(ctx context.Context, db *sql.DB) (_ int64, err error) {
+	ctx, end := startSpan(ctx, "DeleteExpiredRateLimitCounters")
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "DELETE FROM rate_limits WHERE expires_at < $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Types de sujets d'une attribution de rôle
+const (
+	SubjectUser  = "user"
+	SubjectGroup = "group"
+)
+
+// RoleBinding attribue un rôle à un utilisateur ou à un groupe Google, désigné par son email.
+type RoleBinding struct {
+	ID          int       `json:"id"`
+	SubjectType string    `json:"subject_type"`
+	Subject     string    `json:"subject"`
+	Role        string    `json:"role"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func GetAllRoleBindings(ctx context.Context, db *sql.DB) (bindings []RoleBinding, err error) {
+	ctx, end := startSpan(ctx, "GetAllRoleBindings")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, subject_type, subject, role, created_by, created_at FROM role_bindings ORDER BY subject_type, subject")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var binding RoleBinding
+		if err := rows.Scan(&binding.ID, &binding.SubjectType, &binding.Subject, &binding.Role, &binding.CreatedBy, &binding.CreatedAt); err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, rows.Err()
+}
+
+// SaveRoleBinding crée l'attribution, ou remplace le rôle si le sujet en a déjà un.
+func SaveRoleBinding(ctx context.Context, db *sql.DB, binding RoleBinding) (_ *RoleBinding, err error) {
+	ctx, end := startSpan(ctx, "SaveRoleBinding", attribute.String("role_binding.subject", binding.Subject))
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO role_bindings (subject_type, subject, role, created_by, created_at)
+		VALUES ($1, LOWER($2), $3, $4, $5)
+		ON CONFLICT (subject_type, subject) DO UPDATE
+		SET role = EXCLUDED.role, created_by = EXCLUDED.created_by, created_at = EXCLUDED.created_at
+		RETURNING id, subject_type, subject, role, created_by, created_at`
+	err = db.QueryRowContext(ctx, query, binding.SubjectType, binding.Subject, binding.Role, binding.CreatedBy, time.Now()).
+		Scan(&binding.ID, &binding.SubjectType, &binding.Subject, &binding.Role, &binding.CreatedBy, &binding.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// DeleteRoleBinding renvoie false si l'attribution n'existe pas.
+func DeleteRoleBinding(ctx context.Context, db *sql.DB, id int) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "DeleteRoleBinding", attribute.Int("role_binding.id", id))
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "DELETE FROM role_bindings WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
@@ -1,101 +1,202 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Room struct {
-	ID        int       `json:"id"`
-	Slug      string    `json:"slug"`
-	SpaceID   string    `json:"space_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           int        `json:"id"`
+	Slug         string     `json:"slug"`
+	SpaceID      string     `json:"space_id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	OwnerEmail   string     `json:"owner_email,omitempty"` // vide pour les rooms créées avant l'introduction des rôles
+	Team         string     `json:"team,omitempty"`        // groupe de l'auteur pour les rooms créées en libre-service
+	AccessPolicy string     `json:"access_policy"`         // AccessPublic, AccessDomain ou AccessRestricted
+	ArchivedAt   *time.Time `json:"archived_at,omitempty"` // une room archivée n'est plus listée ni accessible
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+const roomColumns = "id, slug, space_id, name, description, COALESCE(owner_email, ''), COALESCE(team, ''), access_policy, archived_at, created_at, updated_at"
+
+func scanRoom(row interface{ Scan(...any) error }) (*Room, error) {
+	room := &Room{}
+	err := row.Scan(&room.ID, &room.Slug, &room.SpaceID, &room.Name, &room.Description, &room.OwnerEmail,
+		&room.Team, &room.AccessPolicy, &room.ArchivedAt, &room.CreatedAt, &room.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+func GetRoomByID(ctx context.Context, db DBTX, id int) (room *Room, err error) {
+	ctx, end := startSpan(ctx, "GetRoomByID", attribute.Int("room.id", id))
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, "SELECT "+roomColumns+" FROM rooms WHERE id = $1", id)
+
+	room, err = scanRoom(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return room, nil
 }
 
-func GetRoomByID(db *sql.DB, id int) (*Room, error) {
-	row := db.QueryRow("SELECT id, slug, space_id, created_at, updated_at FROM rooms WHERE id = $1", id)
+// GetRoomByIDForUpdate renvoie la room comme GetRoomByID et verrouille sa ligne jusqu'à la fin de la transaction.
+func GetRoomByIDForUpdate(ctx context.Context, tx *sql.Tx, id int) (room *Room, err error) {
+	ctx, end := startSpan(ctx, "GetRoomByIDForUpdate", attribute.Int("room.id", id))
+	defer func() { end(err) }()
 
-	var room Room
+	row := tx.QueryRowContext(ctx, "SELECT "+roomColumns+" FROM rooms WHERE id = $1 FOR UPDATE", id)
 
-	err := row.Scan(&room.ID, &room.Slug, &room.SpaceID, &room.CreatedAt, &room.UpdatedAt)
+	room, err = scanRoom(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &room, nil
+	return room, nil
 }
 
-func GetRoomBySlug(db *sql.DB, slug string) (*Room, error) {
-	row := db.QueryRow("SELECT id, slug, space_id, created_at, updated_at FROM rooms WHERE slug = $1", slug)
+func GetRoomBySlug(ctx context.Context, db DBTX, slug string) (room *Room, err error) {
+	ctx, end := startSpan(ctx, "GetRoomBySlug", attribute.String("room.slug", slug))
+	defer func() { end(err) }()
 
-	var room Room
+	row := db.QueryRowContext(ctx, "SELECT "+roomColumns+" FROM rooms WHERE slug = $1", slug)
 
-	err := row.Scan(&room.ID, &room.Slug, &room.SpaceID, &room.CreatedAt, &room.UpdatedAt)
+	room, err = scanRoom(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &room, nil
+	return room, nil
 }
 
-func GetAllRooms(db *sql.DB) ([]Room, error) {
-	var rooms []Room
-	rows, err := db.Query("SELECT id, slug, space_id, created_at, updated_at FROM rooms ORDER BY slug ASC")
+func GetAllRooms(ctx context.Context, db *sql.DB) (rooms []Room, err error) {
+	ctx, end := startSpan(ctx, "GetAllRooms")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT "+roomColumns+" FROM rooms ORDER BY slug ASC")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var room Room
-		if err := rows.Scan(&room.ID, &room.Slug, &room.SpaceID, &room.CreatedAt, &room.UpdatedAt); err != nil {
+		room, err := scanRoom(rows)
+		if err != nil {
 			return nil, err
 		}
-		rooms = append(rooms, room)
+		rooms = append(rooms, *room)
 	}
 
-	return rooms, nil
+	return rooms, rows.Err()
 }
 
-func CreateRoom(db *sql.DB, room Room) (*Room, error) {
-	query := `
-		INSERT INTO rooms (slug, space_id, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING id, slug, space_id, created_at, updated_at`
+// CreateRoom enregistre la room ; elle renvoie nil si le slug a été pris entre-temps.
+func CreateRoom(ctx context.Context, db DBTX, room Room) (_ *Room, err error) {
+	ctx, end := startSpan(ctx, "CreateRoom", attribute.String("room.slug", room.Slug))
+	defer func() { end(err) }()
 
-	err := db.QueryRow(query, room.Slug, room.SpaceID, time.Now(), time.Now()).
-		Scan(&room.ID, &room.Slug, &room.SpaceID, &room.CreatedAt, &room.UpdatedAt)
+	query := `
+		INSERT INTO rooms (slug, space_id, name, description, owner_email, team, access_policy, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING ` + roomColumns
 
+	created, err := scanRoom(db.QueryRowContext(ctx, query, room.Slug, room.SpaceID, room.Name, room.Description, room.OwnerEmail,
+		room.Team, room.AccessPolicy, time.Now(), time.Now()))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
-	return &room, nil
+	return created, nil
 }
 
-func UpdateRoom(db *sql.DB, room Room) error {
+// UpdateRoom enregistre le nom, l'espace Meet, les métadonnées et l'archivage de la room.
+// Elle renvoie ErrSlugTaken si une autre room a pris le slug depuis sa vérification.
+func UpdateRoom(ctx context.Context, db DBTX, room Room) (err error) {
+	ctx, end := startSpan(ctx, "UpdateRoom", attribute.Int("room.id", room.ID))
+	defer func() { end(err) }()
+
 	query := `
-		UPDATE rooms 
-		SET slug = $1, space_id = $2, updated_at = $3 
-		WHERE id = $4`
-	_, err := db.Exec(query, room.Slug, room.SpaceID, time.Now(), room.ID)
+		UPDATE rooms
+		SET slug = $1, space_id = $2, name = $3, description = $4, archived_at = $5, updated_at = $6
+		WHERE id = $7`
+	_, err = db.ExecContext(ctx, query, room.Slug, room.SpaceID, room.Name, room.Description, room.ArchivedAt, time.Now(), room.ID)
+	if isUniqueViolation(err, "rooms_slug_key") {
+		return ErrSlugTaken
+	}
 	return err
 }
 
-func DeleteRoom(db *sql.DB, id int) error {
+func DeleteRoom(ctx context.Context, db DBTX, id int) (err error) {
+	ctx, end := startSpan(ctx, "DeleteRoom", attribute.Int("room.id", id))
+	defer func() { end(err) }()
+
 	query := "DELETE FROM rooms WHERE id = $1"
-	_, err := db.Exec(query, id)
+	_, err = db.ExecContext(ctx, query, id)
 	return err
 }
 
-func GetSpaceIDFromSlug(db *sql.DB, slug string) (string, error) {
+// CountOwnedRooms compte les rooms non archivées dont l'utilisateur est propriétaire.
+func CountOwnedRooms(ctx context.Context, db DBTX, ownerEmail string) (count int, err error) {
+	ctx, end := startSpan(ctx, "CountOwnedRooms")
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rooms WHERE LOWER(owner_email) = LOWER($1) AND archived_at IS NULL", ownerEmail).Scan(&count)
+	return count, err
+}
+
+// CountTeamRooms compte les rooms non archivées de l'équipe.
+func CountTeamRooms(ctx context.Context, db DBTX, team string) (count int, err error) {
+	ctx, end := startSpan(ctx, "CountTeamRooms", attribute.String("room.team", team))
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM rooms WHERE team = $1 AND archived_at IS NULL", team).Scan(&count)
+	return count, err
+}
+
+// LockRoomQuota sérialise, jusqu'à la fin de la transaction, les créations de rooms du propriétaire et de l'équipe :
+// le quota compté ensuite ne peut pas être dépassé par une création concurrente.
+func LockRoomQuota(ctx context.Context, tx *sql.Tx, ownerEmail, team string) (err error) {
+	ctx, end := startSpan(ctx, "LockRoomQuota")
+	defer func() { end(err) }()
+
+	// Toujours le propriétaire puis l'équipe, pour que deux transactions ne s'attendent pas mutuellement
+	keys := []string{"room_quota:user:" + strings.ToLower(ownerEmail)}
+	if team != "" {
+		keys = append(keys, "room_quota:team:"+team)
+	}
+	for _, key := range keys {
+		if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSpaceIDFromSlug(ctx context.Context, db *sql.DB, slug string) (_ string, err error) {
+	ctx, end := startSpan(ctx, "GetSpaceIDFromSlug", attribute.String("room.slug", slug))
+	defer func() { end(err) }()
+
 	var spaceID string
 	query := "SELECT space_id FROM rooms WHERE slug = $1"
-	err := db.QueryRow(query, slug).Scan(&spaceID)
+	err = db.QueryRowContext(ctx, query, slug).Scan(&spaceID)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Politiques d'accès aux rooms
+const (
+	AccessPublic     = "public"     // tout le monde
+	AccessDomain     = "domain"     // tout utilisateur connecté
+	AccessRestricted = "restricted" // utilisateurs et groupes listés, propriétaire et administrateurs
+)
+
+var AccessPolicies = []string{AccessPublic, AccessDomain, AccessRestricted}
+
+// RoomAccess autorise un utilisateur ou un groupe (SubjectUser, SubjectGroup) à accéder à une room restreinte.
+type RoomAccess struct {
+	SubjectType string `json:"subject_type"`
+	Subject     string `json:"subject"`
+}
+
+func GetRoomAccess(ctx context.Context, db DBTX, roomID int) (entries []RoomAccess, err error) {
+	ctx, end := startSpan(ctx, "GetRoomAccess", attribute.Int("room.id", roomID))
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT subject_type, subject FROM room_access WHERE room_id = $1 ORDER BY subject_type, subject", roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry RoomAccess
+		if err := rows.Scan(&entry.SubjectType, &entry.Subject); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetAllRoomAccess renvoie les autorisations de toutes les rooms, par identifiant de room.
+func GetAllRoomAccess(ctx context.Context, db *sql.DB) (_ map[int][]RoomAccess, err error) {
+	ctx, end := startSpan(ctx, "GetAllRoomAccess")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, "SELECT room_id, subject_type, subject FROM room_access")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make(map[int][]RoomAccess)
+	for rows.Next() {
+		var roomID int
+		var entry RoomAccess
+		if err := rows.Scan(&roomID, &entry.SubjectType, &entry.Subject); err != nil {
+			return nil, err
+		}
+		entries[roomID] = append(entries[roomID], entry)
+	}
+	return entries, rows.Err()
+}
+
+// SetRoomAccess remplace la politique d'accès d'une room et ses autorisations ; à appeler dans une transaction.
+func SetRoomAccess(ctx context.Context, db DBTX, roomID int, policy string, entries []RoomAccess) (err error) {
+	ctx, end := startSpan(ctx, "SetRoomAccess", attribute.Int("room.id", roomID), attribute.String("room.access_policy", policy))
+	defer func() { end(err) }()
+
+	if _, err = db.ExecContext(ctx, "UPDATE rooms SET access_policy = $1, updated_at = $2 WHERE id = $3", policy, time.Now(), roomID); err != nil {
+		return err
+	}
+	if _, err = db.ExecContext(ctx, "DELETE FROM room_access WHERE room_id = $1", roomID); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO room_access (room_id, subject_type, subject) VALUES ($1, $2, LOWER($3))
+			ON CONFLICT DO NOTHING`, roomID, entry.SubjectType, entry.Subject)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Statuts d'une demande de room
+const (
+	RequestPending  = "pending"
+	RequestApproved = "approved"
+	RequestRejected = "rejected"
+)
+
+var RequestStatuses = []string{RequestPending, RequestApproved, RequestRejected}
+
+// RoomRequest est une room demandée en libre-service, créée seulement quand un administrateur l'approuve.
+type RoomRequest struct {
+	ID             int        `json:"id"`
+	Slug           string     `json:"slug"`
+	Name           string     `json:"name"`
+	Description    string     `json:"description"`
+	AccessPolicy   string     `json:"access_policy"`
+	Team           string     `json:"team,omitempty"`
+	RequesterEmail string     `json:"requester_email"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"` // motif du refus
+	DecidedBy      string     `json:"decided_by,omitempty"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	RoomID         *int       `json:"room_id,omitempty"` // room créée à l'approbation
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Room renvoie la room à créer pour la demande.
+func (r RoomRequest) Room() Room {
+	return Room{
+		Slug:         r.Slug,
+		Name:         r.Name,
+		Description:  r.Description,
+		OwnerEmail:   r.RequesterEmail,
+		Team:         r.Team,
+		AccessPolicy: r.AccessPolicy,
+	}
+}
+
+const roomRequestColumns = `id, slug, name, description, access_policy, COALESCE(team, ''), requester_email, status, reason,
+	COALESCE(decided_by, ''), decided_at, room_id, created_at`
+
+func scanRoomRequest(row interface{ Scan(...any) error }) (*RoomRequest, error) {
+	request := &RoomRequest{}
+	var roomID sql.NullInt32
+	err := row.Scan(&request.ID, &request.Slug, &request.Name, &request.Description, &request.AccessPolicy, &request.Team,
+		&request.RequesterEmail, &request.Status, &request.Reason, &request.DecidedBy, &request.DecidedAt, &roomID, &request.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if roomID.Valid {
+		id := int(roomID.Int32)
+		request.RoomID = &id
+	}
+	return request, nil
+}
+
+func GetRoomRequestByID(ctx context.Context, db DBTX, id int) (request *RoomRequest, err error) {
+	ctx, end := startSpan(ctx, "GetRoomRequestByID", attribute.Int("room_request.id", id))
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, "SELECT "+roomRequestColumns+" FROM room_requests WHERE id = $1", id)
+
+	request, err = scanRoomRequest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// LockRoomRequest renvoie la demande et la verrouille jusqu'à la fin de la transaction : deux décisions
+// simultanées sur la même demande s'exécutent l'une après l'autre.
+func LockRoomRequest(ctx context.Context, tx *sql.Tx, id int) (request *RoomRequest, err error) {
+	ctx, end := startSpan(ctx, "LockRoomRequest", attribute.Int("room_request.id", id))
+	defer func() { end(err) }()
+
+	row := tx.QueryRowContext(ctx, "SELECT "+roomRequestColumns+" FROM room_requests WHERE id = $1 FOR UPDATE", id)
+
+	request, err = scanRoomRequest(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetRoomRequests renvoie les demandes, filtrées par statut et par demandeur si ceux-ci ne sont pas vides,
+// des plus récentes aux plus anciennes.
+func GetRoomRequests(ctx context.Context, db *sql.DB, status, requesterEmail string) (requests []RoomRequest, err error) {
+	ctx, end := startSpan(ctx, "GetRoomRequests", attribute.String("room_request.status", status))
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+roomRequestColumns+` FROM room_requests
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR LOWER(requester_email) = LOWER($2))
+		ORDER BY created_at DESC`, status, requesterEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		request, err := scanRoomRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	return requests, rows.Err()
+}
+
+// CreateRoomRequest enregistre la demande ; elle renvoie false si le nom est déjà demandé par une demande en attente.
+func CreateRoomRequest(ctx context.Context, db DBTX, request *RoomRequest) (created bool, err error) {
+	ctx, end := startSpan(ctx, "CreateRoomRequest", attribute.String("room.slug", request.Slug))
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO room_requests (slug, name, description, access_policy, team, requester_email, status, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (slug) WHERE status = 'pending' DO NOTHING
+		RETURNING id, status, created_at`
+	err = db.QueryRowContext(ctx, query, request.Slug, request.Name, request.Description, request.AccessPolicy, request.Team,
+		request.RequesterEmail, RequestPending, time.Now()).
+		Scan(&request.ID, &request.Status, &request.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HasPendingRoomRequest indique si le nom est déjà demandé par une demande en attente.
+func HasPendingRoomRequest(ctx context.Context, db DBTX, slug string) (exists bool, err error) {
+	ctx, end := startSpan(ctx, "HasPendingRoomRequest", attribute.String("room.slug", slug))
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM room_requests WHERE slug = $1 AND status = $2)", slug, RequestPending).Scan(&exists)
+	return exists, err
+}
+
+// CountPendingRoomRequests compte les demandes en attente de l'utilisateur et de l'équipe (team vide : aucune).
+func CountPendingRoomRequests(ctx context.Context, db DBTX, requesterEmail, team string) (byUser, byTeam int, err error) {
+	ctx, end := startSpan(ctx, "CountPendingRoomRequests")
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE LOWER(requester_email) = LOWER($1)),
+		       COUNT(*) FILTER (WHERE team = NULLIF($2, ''))
+		FROM room_requests WHERE status = $3`, requesterEmail, team, RequestPending).Scan(&byUser, &byTeam)
+	return byUser, byTeam, err
+}
+
+// DecideRoomRequest enregistre la décision sur une demande encore en attente ; elle renvoie false
+// si la demande n'existe pas ou a déjà été traitée.
+func DecideRoomRequest(ctx context.Context, db DBTX, request *RoomRequest) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "DecideRoomRequest", attribute.Int("room_request.id", request.ID), attribute.String("room_request.status", request.Status))
+	defer func() { end(err) }()
+
+	now := time.Now()
+	result, err := db.ExecContext(ctx, `
+		UPDATE room_requests SET status = $1, reason = $2, decided_by = $3, decided_at = $4, room_id = $5
+		WHERE id = $6 AND status = $7`,
+		request.Status, request.Reason, request.DecidedBy, now, request.RoomID, request.ID, RequestPending)
+	if err != nil {
+		return false, err
+	}
+	decided, err := result.RowsAffected()
+	if decided > 0 {
+		request.DecidedAt = &now
+	}
+	return decided > 0, err
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RoomReservation retient le nom et le quota d'une room le temps de créer son espace Meet, hors transaction.
+// Une réservation expirée est celle d'une création interrompue : elle ne compte plus et son nom peut être repris.
+type RoomReservation struct {
+	ID         int
+	Slug       string
+	OwnerEmail string
+	Team       string
+	ExpiresAt  time.Time
+}
+
+// ReserveRoom réserve le nom de la room jusqu'à expiresAt ; elle renvoie nil si une création en cours l'a déjà réservé.
+func ReserveRoom(ctx context.Context, db DBTX, room Room, expiresAt time.Time) (_ *RoomReservation, err error) {
+	ctx, end := startSpan(ctx, "ReserveRoom", attribute.String("room.slug", room.Slug))
+	defer func() { end(err) }()
+
+	if _, err = db.ExecContext(ctx, "DELETE FROM room_reservations WHERE expires_at < $1", time.Now()); err != nil {
+		return nil, err
+	}
+
+	reservation := &RoomReservation{Slug: room.Slug, OwnerEmail: room.OwnerEmail, Team: room.Team, ExpiresAt: expiresAt}
+	err = db.QueryRowContext(ctx, `
+		INSERT INTO room_reservations (slug, owner_email, team, expires_at) VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+		ON CONFLICT (slug) DO NOTHING
+		RETURNING id`, room.Slug, room.OwnerEmail, room.Team, expiresAt).Scan(&reservation.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// IsRoomSlugReserved indique si le nom est réservé par une création en cours.
+func IsRoomSlugReserved(ctx context.Context, db DBTX, slug string) (reserved bool, err error) {
+	ctx, end := startSpan(ctx, "IsRoomSlugReserved", attribute.String("room.slug", slug))
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM room_reservations WHERE slug = $1 AND expires_at >= $2)", slug, time.Now()).Scan(&reserved)
+	return reserved, err
+}
+
+// CountRoomReservations compte les créations en cours de l'utilisateur et de l'équipe (team vide : aucune).
+func CountRoomReservations(ctx context.Context, db DBTX, ownerEmail, team string) (byUser, byTeam int, err error) {
+	ctx, end := startSpan(ctx, "CountRoomReservations")
+	defer func() { end(err) }()
+
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE LOWER(owner_email) = LOWER($1)),
+		       COUNT(*) FILTER (WHERE team = NULLIF($2, ''))
+		FROM room_reservations WHERE expires_at >= $3`, ownerEmail, team, time.Now()).Scan(&byUser, &byTeam)
+	return byUser, byTeam, err
+}
+
+// ReleaseRoomReservation supprime la réservation, une fois la room enregistrée ou sa création abandonnée.
+func ReleaseRoomReservation(ctx context.Context, db DBTX, id int) (err error) {
+	ctx, end := startSpan(ctx, "ReleaseRoomReservation", attribute.Int("room_reservation.id", id))
+	defer func() { end(err) }()
+
+	_, err = db.ExecContext(ctx, "DELETE FROM room_reservations WHERE id = $1", id)
+	return err
+}
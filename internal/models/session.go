@@ -0,0 +1,145 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Session est une session navigateur conservée côté serveur. L'identifiant est l'empreinte
+// SHA-256 du jeton porté par le cookie ; les données sont chiffrées par le store.
+type Session struct {
+	ID         string     `json:"id"`
+	Data       []byte     `json:"-"`
+	UserEmail  string     `json:"user_email"` // vide tant que l'utilisateur n'est pas connecté
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+const sessionColumns = "id, data, COALESCE(user_email, ''), ip, user_agent, created_at, updated_at, last_seen_at, expires_at, revoked_at"
+
+func scanSession(row interface{ Scan(...any) error }) (*Session, error) {
+	session := &Session{}
+	err := row.Scan(&session.ID, &session.Data, &session.UserEmail, &session.IP, &session.UserAgent,
+		&session.CreatedAt, &session.UpdatedAt, &session.LastSeenAt, &session.ExpiresAt, &session.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSession renvoie une session non expirée, révoquée ou non, ou nil si elle n'existe pas.
+func GetSession(ctx context.Context, db *sql.DB, id string) (session *Session, err error) {
+	ctx, end := startSpan(ctx, "GetSession")
+	defer func() { end(err) }()
+
+	row := db.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE id = $1 AND expires_at > $2`, id, time.Now())
+
+	session, err = scanSession(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetActiveSessions renvoie les sessions connectées, non expirées et non révoquées, les plus récentes d'abord.
+// Un email vide renvoie les sessions de tous les utilisateurs.
+func GetActiveSessions(ctx context.Context, db *sql.DB, userEmail string) (_ []Session, err error) {
+	ctx, end := startSpan(ctx, "GetActiveSessions")
+	defer func() { end(err) }()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_email IS NOT NULL AND revoked_at IS NULL AND expires_at > $1
+		  AND ($2 = '' OR user_email = $2)
+		ORDER BY last_seen_at DESC`, time.Now(), userEmail)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		session.Data = nil
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// SaveSession crée ou met à jour une session et repousse son expiration.
+// Une session révoquée n'est plus jamais modifiée.
+func SaveSession(ctx context.Context, db *sql.DB, session Session) (err error) {
+	ctx, end := startSpan(ctx, "SaveSession")
+	defer func() { end(err) }()
+
+	query := `
+		INSERT INTO sessions (id, data, user_email, ip, user_agent, created_at, updated_at, last_seen_at, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $6, $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET data = EXCLUDED.data, user_email = EXCLUDED.user_email, ip = EXCLUDED.ip, user_agent = EXCLUDED.user_agent,
+		    updated_at = EXCLUDED.updated_at, last_seen_at = EXCLUDED.last_seen_at, expires_at = EXCLUDED.expires_at
+		WHERE sessions.revoked_at IS NULL`
+	_, err = db.ExecContext(ctx, query, session.ID, session.Data, session.UserEmail, session.IP, session.UserAgent, time.Now(), session.ExpiresAt)
+	return err
+}
+
+// RevokeSession révoque une session ; elle renvoie false si la session n'existe pas ou est déjà révoquée.
+func RevokeSession(ctx context.Context, db *sql.DB, id string) (_ bool, err error) {
+	ctx, end := startSpan(ctx, "RevokeSession")
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "UPDATE sessions SET revoked_at = $1, data = ''::bytea WHERE id = $2 AND revoked_at IS NULL", time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	revoked, err := result.RowsAffected()
+	return revoked > 0, err
+}
+
+// RevokeUserSessions révoque toutes les sessions d'un utilisateur et renvoie leur nombre.
+func RevokeUserSessions(ctx context.Context, db *sql.DB, userEmail string) (_ int64, err error) {
+	ctx, end := startSpan(ctx, "RevokeUserSessions")
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "UPDATE sessions SET revoked_at = $1, data = ''::bytea WHERE user_email = $2 AND revoked_at IS NULL", time.Now(), userEmail)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func DeleteSession(ctx context.Context, db *sql.DB, id string) (err error) {
+	ctx, end := startSpan(ctx, "DeleteSession")
+	defer func() { end(err) }()
+
+	_, err = db.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1", id)
+	return err
+}
+
+// DeleteExpiredSessions supprime les sessions expirées et renvoie leur nombre.
+func DeleteExpiredSessions(ctx context.Context, db *sql.DB) (_ int64, err error) {
+	ctx, end := startSpan(ctx, "DeleteExpiredSessions")
+	defer func() { end(err) }()
+
+	result, err := db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= $1", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
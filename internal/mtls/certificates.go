@@ -0,0 +1,132 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"groom/internal/config"
+)
+
+// Certificates sert le certificat du serveur et, si un bundle est configuré, les autorités acceptées pour
+// les certificats clients. Les fichiers sont relus lorsqu'ils changent, sans redémarrer le serveur.
+type Certificates struct {
+	certFile, keyFile, clientCAFile string
+
+	cert      atomic.Pointer[tls.Certificate]
+	clientCAs atomic.Pointer[x509.CertPool]
+	stamps    map[string]time.Time
+}
+
+// Load renvoie nil si aucun certificat n'est configuré : le serveur reste alors en HTTP.
+func Load(cfg config.Config) (*Certificates, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+	c := &Certificates{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile, clientCAFile: cfg.TLSClientCAFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	c.stamps = c.snapshot()
+	return c, nil
+}
+
+func (c *Certificates) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load the TLS certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if c.clientCAFile != "" {
+		pem, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the client CA bundle: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in the client CA bundle %s", c.clientCAFile)
+		}
+	}
+
+	c.cert.Store(&cert)
+	c.clientCAs.Store(pool)
+	return nil
+}
+
+// TLSConfig renvoie la configuration du serveur. Avec un bundle d'autorités, les certificats clients sont
+// demandés et vérifiés s'ils sont présentés ; les clients sans certificat s'authentifient autrement.
+func (c *Certificates) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			serverConfig := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*c.cert.Load()},
+				NextProtos:   []string{"h2", "http/1.1"},
+			}
+			if pool := c.clientCAs.Load(); pool != nil {
+				serverConfig.ClientCAs = pool
+				serverConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			return serverConfig, nil
+		},
+	}
+}
+
+// Watch renvoie une tâche de fond qui recharge les fichiers quand leur date de modification change.
+// En cas d'erreur (fichier en cours d'écriture, paire incohérente), les certificats courants sont conservés.
+func (c *Certificates) Watch(interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stamps := c.snapshot()
+				if sameStamps(stamps, c.stamps) {
+					continue
+				}
+				c.stamps = stamps
+				if err := c.load(); err != nil {
+					slog.ErrorContext(ctx, "TLS certificate reload failed, keeping the current certificates", slog.Any("error", err))
+					continue
+				}
+				slog.InfoContext(ctx, "TLS certificates reloaded")
+			}
+		}
+	}
+}
+
+func (c *Certificates) snapshot() map[string]time.Time {
+	stamps := make(map[string]time.Time)
+	for _, path := range []string{c.certFile, c.keyFile, c.clientCAFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = info.ModTime()
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if !b[path].Equal(stamp) {
+			return false
+		}
+	}
+	return true
+}
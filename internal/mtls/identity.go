@@ -0,0 +1,72 @@
+package mtls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"groom/internal/apikey"
+)
+
+// Préfixes des identités d'un certificat client : nom commun du sujet ou entrée du SAN
+const (
+	IdentityCN    = "cn"
+	IdentityDNS   = "dns"
+	IdentityURI   = "uri"
+	IdentityEmail = "email"
+)
+
+// Mapping associe les identités des certificats clients ("cn:svc-backup", "uri:spiffe://corp/ns/svc") à leurs scopes.
+type Mapping map[string][]string
+
+// ParseMapping lit les associations "type:valeur=scope". Une même identité peut apparaître plusieurs fois
+// pour accorder plusieurs scopes.
+func ParseMapping(entries []string) (Mapping, error) {
+	mapping := make(Mapping)
+	for _, entry := range entries {
+		// La valeur peut elle-même contenir "=" (URI, DN) : le scope suit le dernier
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid tls_client_identity_mapping entry %q (expected type:value=scope)", entry)
+		}
+		identity, scope := strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		kind, value, ok := strings.Cut(identity, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid tls_client_identity_mapping entry %q (expected type:value=scope)", entry)
+		}
+		switch kind {
+		case IdentityCN, IdentityDNS, IdentityURI, IdentityEmail:
+		default:
+			return nil, fmt.Errorf("invalid tls_client_identity_mapping entry %q: type must be cn, dns, uri or email", entry)
+		}
+		if err := apikey.ValidateScopes([]string{scope}); err != nil {
+			return nil, fmt.Errorf("invalid tls_client_identity_mapping entry %q: %w", entry, err)
+		}
+		mapping[identity] = append(mapping[identity], scope)
+	}
+	return mapping, nil
+}
+
+// Identify renvoie la première identité connue du certificat et ses scopes. Le SAN est consulté avant le sujet.
+func (m Mapping) Identify(cert *x509.Certificate) (string, []string, bool) {
+	var identities []string
+	for _, uri := range cert.URIs {
+		identities = append(identities, IdentityURI+":"+uri.String())
+	}
+	for _, name := range cert.DNSNames {
+		identities = append(identities, IdentityDNS+":"+name)
+	}
+	for _, email := range cert.EmailAddresses {
+		identities = append(identities, IdentityEmail+":"+email)
+	}
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, IdentityCN+":"+cert.Subject.CommonName)
+	}
+
+	for _, identity := range identities {
+		if scopes, ok := m[identity]; ok {
+			return identity, scopes, true
+		}
+	}
+	return "", nil, false
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"groom/internal/config"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// En-tête portant la signature HMAC-SHA256 du corps du webhook : sha256=<hex>
+const SignatureHeader = "X-Groom-Signature"
+
+// Event est une notification envoyée au webhook et, si To est renseigné, par email à son destinataire.
+type Event struct {
+	Type    string    `json:"type"` // par exemple room_request.approved
+	To      string    `json:"to,omitempty"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	Data    any       `json:"data,omitempty"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Notifier envoie les notifications au webhook et par SMTP, selon la configuration.
+type Notifier struct {
+	webhookURL    string
+	webhookSecret []byte
+	client        *http.Client
+
+	smtpAddr string
+	smtpAuth smtp.Auth
+	smtpFrom string
+}
+
+// New renvoie nil si ni webhook ni serveur SMTP ne sont configurés : les notifications sont alors désactivées.
+func New(cfg config.Config) (*Notifier, error) {
+	if cfg.NotifyWebhookURL == "" && cfg.SMTPAddr == "" {
+		return nil, nil
+	}
+	n := &Notifier{
+		webhookURL: cfg.NotifyWebhookURL,
+		client:     &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		smtpAddr:   cfg.SMTPAddr,
+		smtpFrom:   cfg.SMTPFrom,
+	}
+	if cfg.NotifyWebhookSecret != "" {
+		key, err := config.DecodeKey(cfg.NotifyWebhookSecret)
+		if err != nil {
+			return nil, fmt.Errorf("notify webhook secret: %w", err)
+		}
+		n.webhookSecret = key
+	}
+	if cfg.SMTPUsername != "" {
+		host, _, _ := net.SplitHostPort(cfg.SMTPAddr)
+		n.smtpAuth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, host)
+	}
+	return n, nil
+}
+
+// Send envoie l'événement à tous les canaux configurés et renvoie leurs erreurs.
+// Un Notifier nil n'envoie rien.
+func (n *Notifier) Send(ctx context.Context, event Event) error {
+	if n == nil {
+		return nil
+	}
+	if event.SentAt.IsZero() {
+		event.SentAt = time.Now()
+	}
+
+	var errs []error
+	if n.webhookURL != "" {
+		if err := n.postWebhook(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("webhook: %w", err))
+		}
+	}
+	if n.smtpAddr != "" && event.To != "" {
+		if err := n.sendMail(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("email: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) postWebhook(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.webhookSecret != nil {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.webhookSecret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign renvoie la signature HMAC-SHA256 hexadécimale du corps, à comparer par le destinataire du webhook.
+func Sign(key, body []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sendMail envoie l'email comme smtp.SendMail (STARTTLS si le serveur le propose, puis authentification),
+// mais la connexion et toute la conversation SMTP sont bornées par ctx.
+func (n *Notifier) sendMail(ctx context.Context, event Event) error {
+	if strings.ContainsAny(event.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", event.To)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.smtpFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", event.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", event.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.SentAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(event.Text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.smtpAddr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	// Une annulation interrompt la lecture ou l'écriture en cours
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(n.smtpAddr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.smtpAuth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("the SMTP server does not support authentication")
+		}
+		if err := client.Auth(n.smtpAuth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(n.smtpFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(event.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
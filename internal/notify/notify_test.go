@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"groom/internal/config"
+)
+
+func newMailNotifier(t *testing.T, addr string) *Notifier {
+	t.Helper()
+	n, err := New(config.Config{SMTPAddr: addr, SMTPFrom: "groom@example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+// TestSendMailHonorsContext couvre un serveur qui accepte la connexion sans jamais répondre.
+func TestSendMailHonorsContext(t *testing.T) {
+	l := listen(t)
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := l.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	defer func() {
+		if conn := <-accepted; conn != nil {
+			conn.Close()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := newMailNotifier(t, l.Addr().String()).Send(ctx, Event{To: "alice@example.test", Subject: "Test", Text: "Bonjour"})
+	if err == nil {
+		t.Fatal("Send() succeeded, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("Send() returned after %v, want the context deadline", elapsed)
+	}
+}
+
+// TestSendMail joue une conversation SMTP minimale et vérifie le message reçu.
+func TestSendMail(t *testing.T) {
+	l := listen(t)
+	received := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 smtp.example.test")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				received <- data.String()
+				reply("250 OK")
+			case inData:
+				data.WriteString(line)
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 smtp.example.test")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 Go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := newMailNotifier(t, l.Addr().String()).Send(ctx, Event{To: "alice@example.test", Subject: "Salle prête", Text: "Bonjour\nÀ bientôt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-received
+	for _, want := range []string{"To: alice@example.test\r\n", "Subject: =?utf-8?q?Salle_pr=C3=AAte?=\r\n", "Bonjour\r\nÀ bientôt\r\n"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message does not contain %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	err := newMailNotifier(t, "127.0.0.1:1").Send(context.Background(), Event{To: "alice@example.test\r\nBcc: eve@example.test"})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+		t.Fatalf("Send() = %v, want an invalid recipient error", err)
+	}
+}
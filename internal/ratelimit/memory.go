@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type counter struct {
+	windowStart time.Time
+	windowEnd   time.Time
+	count       int
+}
+
+// MemoryLimiter garde les compteurs en mémoire : chaque instance applique ses propres limites.
+type MemoryLimiter struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{counters: make(map[string]*counter)}
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	now := clock()
+	start := now.Truncate(rule.Window)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counters[key]
+	if !ok || !c.windowStart.Equal(start) {
+		c = &counter{windowStart: start, windowEnd: start.Add(rule.Window)}
+		l.counters[key] = c
+	}
+	c.count++
+	return decide(c.count, rule, c.windowEnd, now), nil
+}
+
+func (l *MemoryLimiter) Purge(context.Context) error {
+	now := clock()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, c := range l.counters {
+		if !c.windowEnd.After(now) {
+			delete(l.counters, key)
+		}
+	}
+	return nil
+}
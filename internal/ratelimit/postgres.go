@@ -0,0 +1,34 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+
+	"groom/internal/models"
+)
+
+// PostgresLimiter partage les compteurs entre toutes les instances, au prix d'une requête SQL par décision.
+type PostgresLimiter struct {
+	db *sql.DB
+}
+
+func NewPostgresLimiter(db *sql.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db}
+}
+
+func (l *PostgresLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := clock()
+	start := now.Truncate(rule.Window)
+	end := start.Add(rule.Window)
+
+	count, err := models.IncrementRateLimitCounter(ctx, l.db, key, start, end)
+	if err != nil {
+		return Result{}, err
+	}
+	return decide(count, rule, end, now), nil
+}
+
+func (l *PostgresLimiter) Purge(ctx context.Context) error {
+	_, err := models.DeleteExpiredRateLimitCounters(ctx, l.db)
+	return err
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Horloge des limiteurs, remplacée dans les tests
+var clock = time.Now
+
+// Rule autorise Limit requêtes par fenêtre fixe de durée Window.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result est la décision prise pour une requête.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // délai avant la prochaine fenêtre, lorsque la requête est refusée
+}
+
+// Limiter compte les requêtes par clé (adresse IP, client de l'API, utilisateur).
+// MemoryLimiter convient à une instance unique ; PostgresLimiter partage les compteurs entre instances.
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+	// Purge supprime les compteurs des fenêtres terminées.
+	Purge(ctx context.Context) error
+}
+
+// decide applique la règle au nombre de requêtes de la fenêtre courante.
+func decide(count int, rule Rule, windowEnd, now time.Time) Result {
+	if count > rule.Limit {
+		return Result{RetryAfter: windowEnd.Sub(now)}
+	}
+	return Result{Allowed: true, Remaining: rule.Limit - count}
+}
+
+// Cleanup renvoie une tâche de fond qui purge périodiquement les compteurs expirés.
+func Cleanup(limiter Limiter, interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := limiter.Purge(ctx); err != nil {
+					slog.ErrorContext(ctx, "Failed to purge rate limit counters", slog.Any("error", err))
+				}
+			}
+		}
+	}
+}
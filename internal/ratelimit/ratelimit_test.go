@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// setClock fige l'horloge des limiteurs pour la durée du test et renvoie de quoi l'avancer.
+func setClock(t *testing.T, start time.Time) func(time.Duration) {
+	t.Helper()
+	now := start
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = time.Now })
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func allow(t *testing.T, limiter Limiter, key string, rule Rule) Result {
+	t.Helper()
+	result, err := limiter.Allow(context.Background(), key, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	rule := Rule{Limit: 3, Window: time.Minute}
+	advance := setClock(t, time.Date(2026, 1, 5, 10, 0, 15, 0, time.UTC))
+	limiter := NewMemoryLimiter()
+
+	for i := 1; i <= rule.Limit; i++ {
+		result := allow(t, limiter, "ip:192.0.2.1", rule)
+		if !result.Allowed || result.Remaining != rule.Limit-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, result, rule.Limit-i)
+		}
+	}
+
+	result := allow(t, limiter, "ip:192.0.2.1", rule)
+	if result.Allowed {
+		t.Fatalf("request %d allowed, want blocked", rule.Limit+1)
+	}
+	if result.RetryAfter != 45*time.Second {
+		t.Fatalf("RetryAfter = %v, want the end of the window (45s)", result.RetryAfter)
+	}
+
+	if result := allow(t, limiter, "ip:192.0.2.2", rule); !result.Allowed {
+		t.Fatal("another key blocked, want its own counter")
+	}
+
+	advance(result.RetryAfter)
+	if result := allow(t, limiter, "ip:192.0.2.1", rule); !result.Allowed || result.Remaining != rule.Limit-1 {
+		t.Fatalf("after the window: %+v, want a new counter", result)
+	}
+}
+
+func TestMemoryLimiterPurge(t *testing.T) {
+	rule := Rule{Limit: 1, Window: time.Minute}
+	advance := setClock(t, time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC))
+	limiter := NewMemoryLimiter()
+
+	allow(t, limiter, "ip:192.0.2.1", rule)
+	if err := limiter.Purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.counters) != 1 {
+		t.Fatalf("%d counters, want the current window kept", len(limiter.counters))
+	}
+
+	advance(rule.Window)
+	if err := limiter.Purge(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.counters) != 0 {
+		t.Fatalf("%d counters, want finished windows purged", len(limiter.counters))
+	}
+}
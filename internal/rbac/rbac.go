@@ -0,0 +1,206 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"groom/internal/apikey"
+	"groom/internal/config"
+	"groom/internal/models"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Role est un niveau d'accès ; chaque rôle inclut les droits des précédents.
+type Role string
+
+const (
+	None   Role = ""
+	Viewer Role = "viewer" // liste des salles
+	Editor Role = "editor" // création de salles, modification de ses propres salles
+	Admin  Role = "admin"  // toutes les salles, sessions, clés d'API et rôles
+)
+
+var Roles = []Role{Viewer, Editor, Admin}
+
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(value)))
+	if !slices.Contains(Roles, role) {
+		return None, fmt.Errorf("unknown role %q (expected viewer, editor or admin)", value)
+	}
+	return role, nil
+}
+
+func (r Role) rank() int {
+	return slices.Index(Roles, r) + 1
+}
+
+// Includes indique si le rôle donne au moins les droits de other.
+func (r Role) Includes(other Role) bool {
+	return r.rank() >= other.rank()
+}
+
+// Scopes renvoie les scopes d'API équivalents au rôle, vérifiés par les mêmes middlewares que les clés d'API.
+func (r Role) Scopes() []string {
+	switch r {
+	case Viewer:
+		return []string{apikey.ScopeRoomsRead}
+	case Editor:
+		return []string{apikey.ScopeRoomsRead, apikey.ScopeRoomsWrite}
+	case Admin:
+		return apikey.Scopes
+	}
+	return nil
+}
+
+// GroupResolver renvoie les groupes (emails en minuscules) d'un utilisateur.
+type GroupResolver interface {
+	UserGroups(ctx context.Context, email string) ([]string, error)
+}
+
+// Durée pendant laquelle les attributions de rôles lues en base sont réutilisées
+const bindingsTTL = 30 * time.Second
+
+// Resolver détermine le rôle d'un utilisateur : le plus élevé entre les administrateurs de la configuration
+// (admin_emails), ses attributions directes, celles de ses groupes et le rôle par défaut.
+type Resolver struct {
+	db       *sql.DB
+	cfgStore *config.Store
+	groups   GroupResolver // nil si les attributions par groupe sont désactivées
+	cache    *cache.Cache
+}
+
+func NewResolver(db *sql.DB, cfgStore *config.Store, groups GroupResolver) *Resolver {
+	return &Resolver{
+		db:       db,
+		cfgStore: cfgStore,
+		groups:   groups,
+		cache:    cache.New(bindingsTTL, time.Minute),
+	}
+}
+
+func (r *Resolver) Role(ctx context.Context, email string) (Role, error) {
+	cfg := r.cfgStore.Get()
+	email = strings.ToLower(email)
+
+	if slices.ContainsFunc(cfg.AdminEmails, func(admin string) bool { return strings.EqualFold(admin, email) }) {
+		return Admin, nil
+	}
+
+	role := Role(strings.ToLower(cfg.RBACDefaultRole))
+	if role == "none" {
+		role = None
+	}
+
+	bindings, err := r.bindings(ctx)
+	if err != nil {
+		return None, err
+	}
+
+	var groups []string
+	for _, binding := range bindings {
+		bound := Role(binding.Role)
+		if bound.rank() <= role.rank() {
+			continue
+		}
+
+		switch binding.SubjectType {
+		case models.SubjectUser:
+			if binding.Subject == email {
+				role = bound
+			}
+		case models.SubjectGroup:
+			if r.groups == nil {
+				continue
+			}
+			if groups == nil {
+				if groups, err = r.groups.UserGroups(ctx, email); err != nil {
+					return None, fmt.Errorf("unable to resolve groups of %s: %w", email, err)
+				}
+				if groups == nil {
+					groups = []string{}
+				}
+			}
+			if slices.Contains(groups, binding.Subject) {
+				role = bound
+			}
+		}
+	}
+	return role, nil
+}
+
+func (r *Resolver) bindings(ctx context.Context) ([]models.RoleBinding, error) {
+	if cached, found := r.cache.Get("bindings"); found {
+		return cached.([]models.RoleBinding), nil
+	}
+	bindings, err := models.GetAllRoleBindings(ctx, r.db)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.SetDefault("bindings", bindings)
+	return bindings, nil
+}
+
+// UserGroups renvoie les groupes de l'utilisateur, ou nil si les groupes ne sont pas résolus.
+func (r *Resolver) UserGroups(ctx context.Context, email string) ([]string, error) {
+	if r.groups == nil {
+		return nil, nil
+	}
+	return r.groups.UserGroups(ctx, email)
+}
+
+// Invalidate force la relecture des attributions après une modification.
+func (r *Resolver) Invalidate() {
+	r.cache.Flush()
+}
+
+// CanAccessRoom applique la politique d'accès de la room à l'utilisateur (email vide s'il n'est pas connecté).
+// Les rooms restreintes restent accessibles à leur propriétaire et aux administrateurs.
+func (r *Resolver) CanAccessRoom(ctx context.Context, email string, role Role, room models.Room, entries []models.RoomAccess) (bool, error) {
+	switch room.AccessPolicy {
+	case models.AccessPublic:
+		return true, nil
+	case models.AccessDomain:
+		return email != "", nil
+	case models.AccessRestricted:
+	default:
+		return false, nil
+	}
+
+	if email == "" {
+		return false, nil
+	}
+	if role.Includes(Admin) || strings.EqualFold(room.OwnerEmail, email) {
+		return true, nil
+	}
+
+	hasGroups := false
+	for _, entry := range entries {
+		switch entry.SubjectType {
+		case models.SubjectUser:
+			if strings.EqualFold(entry.Subject, email) {
+				return true, nil
+			}
+		case models.SubjectGroup:
+			hasGroups = true
+		}
+	}
+	if !hasGroups || r.groups == nil {
+		return false, nil
+	}
+
+	groups, err := r.groups.UserGroups(ctx, email)
+	if err != nil {
+		return false, fmt.Errorf("unable to resolve groups of %s: %w", email, err)
+	}
+	for _, entry := range entries {
+		if entry.SubjectType == models.SubjectGroup && slices.Contains(groups, strings.ToLower(entry.Subject)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
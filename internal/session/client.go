@@ -0,0 +1,43 @@
+package session
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// RevokedKey est présente (à true) dans une session vide qui remplace une session révoquée.
+const RevokedKey = "_revoked"
+
+// regenerateKey demande au store d'émettre un nouveau jeton au prochain enregistrement.
+const regenerateKey = "_regenerate"
+
+// Regenerate remplace le jeton de la session au prochain Save et supprime l'ancien : un jeton connu
+// avant la connexion ne donne pas accès à la session connectée (fixation de session).
+func Regenerate(session sessions.Session) {
+	session.Set(regenerateKey, true)
+}
+
+type clientIPKey struct{}
+
+// ClientIPMiddleware rend l'adresse du client, telle que gin la résout, visible du store de sessions.
+func ClientIPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), clientIPKey{}, c.ClientIP()))
+		c.Next()
+	}
+}
+
+func clientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
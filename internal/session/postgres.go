@@ -0,0 +1,245 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"groom/internal/config"
+	"groom/internal/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+)
+
+// PostgresStore conserve les sessions en base. Le cookie ne contient qu'un jeton opaque signé ;
+// les valeurs de la session (dont le token OAuth) sont chiffrées en AES-GCM avant d'être écrites.
+type PostgresStore struct {
+	db      *sql.DB
+	codecs  []securecookie.Codec
+	ciphers []cipher.AEAD // le premier chiffre, tous déchiffrent (rotation des clés)
+	options *gsessions.Options
+}
+
+func NewPostgresStore(db *sql.DB, cfg config.Config) (*PostgresStore, error) {
+	pairs, err := KeyPairs(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &PostgresStore{
+		db:     db,
+		codecs: securecookie.CodecsFromPairs(pairs...),
+	}
+	// Les clés de chiffrement au repos sont dérivées des clés de signature
+	for i := 0; i < len(pairs); i += 2 {
+		aead, err := newDataCipher(pairs[i])
+		if err != nil {
+			return nil, err
+		}
+		store.ciphers = append(store.ciphers, aead)
+	}
+	store.Options(Options(cfg))
+	return store, nil
+}
+
+func newDataCipher(signingKey []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, signingKey)
+	mac.Write([]byte("groom session data at rest"))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *PostgresStore) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+	for _, codec := range s.codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(options.MaxAge)
+		}
+	}
+}
+
+func (s *PostgresStore) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New renvoie la session désignée par le cookie, ou une session vide si le cookie est absent,
+// invalide, ou si la session a expiré ou été supprimée.
+func (s *PostgresStore) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	options := *s.options
+	session.Options = &options
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil
+	}
+
+	stored, err := models.GetSession(r.Context(), s.db, tokenHash(token))
+	if err != nil {
+		return session, err
+	}
+	if stored == nil {
+		return session, nil
+	}
+	if stored.RevokedAt != nil {
+		// Une nouvelle session (nouveau jeton) remplacera la session révoquée au prochain enregistrement
+		session.Values[RevokedKey] = true
+		return session, nil
+	}
+
+	if err := s.decode(stored.Data, &session.Values); err != nil {
+		slog.WarnContext(r.Context(), "Discarding undecipherable session", slog.Any("error", err))
+		return session, nil
+	}
+	session.ID = token
+	session.IsNew = false
+	return session, nil
+}
+
+// Save enregistre la session et repousse son expiration (expiration glissante), ou la supprime si MaxAge < 0.
+func (s *PostgresStore) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	ctx := r.Context()
+
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := models.DeleteSession(ctx, s.db, tokenHash(session.ID)); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if regenerate, _ := session.Values[regenerateKey].(bool); regenerate {
+		delete(session.Values, regenerateKey)
+		if session.ID != "" {
+			if err := models.DeleteSession(ctx, s.db, tokenHash(session.ID)); err != nil {
+				return err
+			}
+		}
+		session.ID = ""
+	}
+	if session.ID == "" {
+		session.ID = newToken()
+	}
+
+	data, err := s.encode(session.Values)
+	if err != nil {
+		return err
+	}
+
+	userEmail, _ := session.Values["user"].(string)
+	err = models.SaveSession(ctx, s.db, models.Session{
+		ID:        tokenHash(session.ID),
+		Data:      data,
+		UserEmail: userEmail,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		ExpiresAt: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+func (s *PostgresStore) encode(values map[interface{}]interface{}) ([]byte, error) {
+	var plain bytes.Buffer
+	if err := gob.NewEncoder(&plain).Encode(values); err != nil {
+		return nil, err
+	}
+
+	aead := s.ciphers[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain.Bytes(), nil), nil
+}
+
+func (s *PostgresStore) decode(data []byte, values *map[interface{}]interface{}) error {
+	for _, aead := range s.ciphers {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+		plain, err := aead.Open(nil, nonce, sealed, nil)
+		if err != nil {
+			continue
+		}
+		return gob.NewDecoder(bytes.NewReader(plain)).Decode(values)
+	}
+	return errors.New("no session key can decrypt the session data")
+}
+
+// Cleanup supprime régulièrement les sessions expirées, jusqu'à l'annulation de ctx.
+func (s *PostgresStore) Cleanup(interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				deleted, err := models.DeleteExpiredSessions(ctx, s.db)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to delete expired sessions", slog.Any("error", err))
+					continue
+				}
+				if deleted > 0 {
+					slog.InfoContext(ctx, "Expired sessions deleted", slog.Int64("count", deleted))
+				}
+			}
+		}
+	}
+}
+
+func newToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ID renvoie l'identifiant en base d'une session, tel qu'il apparaît dans l'administration des sessions.
+func ID(token string) string {
+	return tokenHash(token)
+}
+
+// tokenHash est la clé de la session en base : une fuite de la table ne permet pas d'usurper une session.
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
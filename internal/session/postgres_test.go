@@ -0,0 +1,261 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"groom/internal/config"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const cookieName = "groom"
+
+// randomKey renvoie une clé de session aléatoire encodée en base64.
+func randomKey(t *testing.T) string {
+	t.Helper()
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func newStore(t *testing.T, db *sql.DB, keys ...string) *PostgresStore {
+	t.Helper()
+	store, err := NewPostgresStore(db, config.Config{SessionSigningKeys: keys, SessionMaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// saveUser enregistre une session connectée et renvoie le cookie émis.
+func saveUser(t *testing.T, store *PostgresStore, email string) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	session, err := store.New(req, cookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Values["user"] = email
+
+	rec := httptest.NewRecorder()
+	if err := store.Save(req, rec, session); err != nil {
+		t.Fatal(err)
+	}
+	return sessionCookie(t, rec)
+}
+
+func sessionCookie(t *testing.T, rec *httptest.ResponseRecorder) *http.Cookie {
+	t.Helper()
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == cookieName {
+			return cookie
+		}
+	}
+	t.Fatal("no session cookie set")
+	return nil
+}
+
+func requestWith(cookie *http.Cookie) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	return req
+}
+
+func TestStoreKeyRotation(t *testing.T) {
+	db, _ := newFakeDB()
+	oldKey, newKey := randomKey(t), randomKey(t)
+	cookie := saveUser(t, newStore(t, db, oldKey), "alice@example.test")
+
+	t.Run("previous key still accepted", func(t *testing.T) {
+		session, err := newStore(t, db, newKey, oldKey).New(requestWith(cookie), cookieName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.IsNew || session.Values["user"] != "alice@example.test" {
+			t.Fatalf("session = %v (new: %v), want the saved session", session.Values, session.IsNew)
+		}
+	})
+
+	t.Run("rotated-out key discarded", func(t *testing.T) {
+		session, err := newStore(t, db, newKey).New(requestWith(cookie), cookieName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !session.IsNew || len(session.Values) != 0 {
+			t.Fatalf("session = %v (new: %v), want an empty session", session.Values, session.IsNew)
+		}
+	})
+}
+
+// TestStoreDiscardsUndecipherableData couvre une session dont le cookie est valide mais dont les données
+// ont été chiffrées avec une clé retirée.
+func TestStoreDiscardsUndecipherableData(t *testing.T) {
+	db, rows := newFakeDB()
+	oldKey, newKey := randomKey(t), randomKey(t)
+	cookie := saveUser(t, newStore(t, db, oldKey), "alice@example.test")
+
+	data, err := newStore(t, db, newKey).encode(map[interface{}]interface{}{"user": "alice@example.test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id := range rows.all() {
+		rows.set(id, data)
+	}
+
+	session, err := newStore(t, db, oldKey).New(requestWith(cookie), cookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.IsNew || len(session.Values) != 0 {
+		t.Fatalf("session = %v (new: %v), want an empty session", session.Values, session.IsNew)
+	}
+}
+
+// TestRegenerate reproduit la fin de la connexion Google : la session anonyme reçoit un nouveau jeton.
+func TestRegenerate(t *testing.T) {
+	db, rows := newFakeDB()
+	store := newStore(t, db, randomKey(t))
+	before := saveUser(t, store, "")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(sessions.Sessions(cookieName, store))
+	r.GET("/", func(c *gin.Context) {
+		session := sessions.Default(c)
+		Regenerate(session)
+		session.Set("user", "alice@example.test")
+		if err := session.Save(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, requestWith(before))
+
+	after := sessionCookie(t, rec)
+	if after.Value == before.Value {
+		t.Fatal("session cookie unchanged across login")
+	}
+	if n := len(rows.all()); n != 1 {
+		t.Fatalf("%d sessions stored, want the previous one deleted", n)
+	}
+
+	old, err := store.New(requestWith(before), cookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !old.IsNew {
+		t.Fatalf("previous cookie still opens session %v", old.Values)
+	}
+	current, err := store.New(requestWith(after), cookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.Values["user"] != "alice@example.test" || current.Values[regenerateKey] != nil {
+		t.Fatalf("session = %v, want the signed-in user only", current.Values)
+	}
+}
+
+// fakeDB imite la table sessions pour les requêtes du store, sans serveur Postgres.
+type fakeDB struct {
+	mu   sync.Mutex
+	rows map[string]fakeRow
+}
+
+type fakeRow struct {
+	data  []byte
+	email string
+}
+
+func newFakeDB() (*sql.DB, *fakeDB) {
+	fake := &fakeDB{rows: map[string]fakeRow{}}
+	return sql.OpenDB(fake), fake
+}
+
+func (f *fakeDB) all() map[string]fakeRow {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make(map[string]fakeRow, len(f.rows))
+	for id, row := range f.rows {
+		rows[id] = row
+	}
+	return rows
+}
+
+func (f *fakeDB) set(id string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	row := f.rows[id]
+	row.data = data
+	f.rows[id] = row
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	id, _ := args[0].Value.(string)
+	switch {
+	case strings.Contains(query, "INSERT INTO sessions"):
+		email, _ := args[2].Value.(string)
+		c.db.rows[id] = fakeRow{data: args[1].Value.([]byte), email: email}
+	case strings.Contains(query, "DELETE FROM sessions WHERE id"):
+		delete(c.db.rows, id)
+	default:
+		return nil, driver.ErrSkip
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if !strings.Contains(query, "FROM sessions") {
+		return nil, driver.ErrSkip
+	}
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	id, _ := args[0].Value.(string)
+	row, ok := c.db.rows[id]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	now := time.Now()
+	return &fakeRows{values: [][]driver.Value{{id, row.data, row.email, "", "", now, now, now, now.Add(time.Hour), nil}}}, nil
+}
+
+type fakeRows struct{ values [][]driver.Value }
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "data", "user_email", "ip", "user_agent", "created_at", "updated_at", "last_seen_at", "expires_at", "revoked_at"}
+}
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
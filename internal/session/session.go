@@ -0,0 +1,67 @@
+package session
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"groom/internal/config"
+
+	"github.com/gin-contrib/sessions"
+)
+
+// Clé utilisée uniquement en mode démo quand aucune clé n'est configurée
+const demoSecret = "groom-demo-session-secret"
+
+// KeyPairs construit les paires (signature, chiffrement) attendues par gorilla/securecookie.
+// La première paire sert à signer ; les suivantes ne servent qu'à vérifier les cookies émis avant une rotation.
+func KeyPairs(cfg config.Config) ([][]byte, error) {
+	if len(cfg.SessionSigningKeys) == 0 {
+		if !cfg.DemoMode {
+			return nil, fmt.Errorf("no session signing key configured")
+		}
+		slog.Warn("Using the built-in demo session key: sessions can be forged, never use demo mode in production")
+		key := sha256.Sum256([]byte(demoSecret))
+		return [][]byte{key[:], nil}, nil
+	}
+
+	pairs := make([][]byte, 0, 2*len(cfg.SessionSigningKeys))
+	for i, encoded := range cfg.SessionSigningKeys {
+		signingKey, err := config.DecodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("session signing key %d: %w", i, err)
+		}
+
+		var encryptionKey []byte
+		if i < len(cfg.SessionEncryptionKeys) {
+			encryptionKey, err = config.DecodeKey(cfg.SessionEncryptionKeys[i])
+			if err != nil {
+				return nil, fmt.Errorf("session encryption key %d: %w", i, err)
+			}
+		}
+		pairs = append(pairs, signingKey, encryptionKey)
+	}
+	return pairs, nil
+}
+
+// Options renvoie les attributs du cookie de session.
+func Options(cfg config.Config) sessions.Options {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(cfg.SessionCookieSameSite) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	return sessions.Options{
+		Path:     "/",
+		Domain:   cfg.SessionCookieDomain,
+		MaxAge:   int(cfg.SessionMaxAge.Seconds()),
+		Secure:   cfg.SessionCookieSecure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+}
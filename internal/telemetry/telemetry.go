@@ -0,0 +1,124 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"groom/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup configure les providers globaux de traces et de métriques et la propagation W3C (traceparent, baggage).
+// La fonction retournée vide les spans et les métriques en attente et doit être appelée à l'arrêt.
+func Setup(ctx context.Context, cfg config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTracing, err := setupTracing(ctx, cfg, res)
+	if err != nil {
+		return nil, err
+	}
+	shutdownMetrics, err := setupMetrics(ctx, cfg, res)
+	if err != nil {
+		shutdownTracing(ctx)
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(shutdownTracing(ctx), shutdownMetrics(ctx))
+	}, nil
+}
+
+func setupTracing(ctx context.Context, cfg config.Config, res *resource.Resource) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracingExporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.TracingEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.TracingExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Sans exporteur, les instruments (compteurs, etc.) restent ceux du provider global par défaut, qui ne fait rien.
+func setupMetrics(ctx context.Context, cfg config.Config, res *resource.Resource) (func(context.Context) error, error) {
+	var exporter sdkmetric.Exporter
+	var err error
+	switch cfg.MetricsExporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdoutmetric.New(stdoutmetric.WithWriter(os.Stdout))
+	case "otlp":
+		var opts []otlpmetrichttp.Option
+		if cfg.TracingEndpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(cfg.TracingEndpoint))
+		}
+		exporter, err = otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown metrics exporter %q", cfg.MetricsExporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// EndSpan enregistre l'éventuelle erreur sur le span puis le termine.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS sessions_user_email_idx;
+
+ALTER TABLE sessions
+    DROP COLUMN IF EXISTS user_email,
+    DROP COLUMN IF EXISTS last_seen_at,
+    DROP COLUMN IF EXISTS ip,
+    DROP COLUMN IF EXISTS user_agent,
+    DROP COLUMN IF EXISTS revoked_at;
@@ -0,0 +1,3 @@
+ALTER TABLE rooms DROP COLUMN IF EXISTS owner_email;
+
+DROP TABLE IF EXISTS role_bindings;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS role_bindings (
+    id SERIAL PRIMARY KEY,
+    subject_type VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (subject_type, subject)
+);
+
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS owner_email VARCHAR(255);
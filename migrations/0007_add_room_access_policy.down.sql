@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS room_access;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS access_policy;
@@ -0,0 +1,8 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS access_policy VARCHAR(16) NOT NULL DEFAULT 'public';
+
+CREATE TABLE IF NOT EXISTS room_access (
+    room_id INTEGER NOT NULL REFERENCES rooms (id) ON DELETE CASCADE,
+    subject_type VARCHAR(16) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    PRIMARY KEY (room_id, subject_type, subject)
+);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    actor VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    room_id INTEGER,
+    before JSONB,
+    after JSONB,
+    reverted_event_id BIGINT REFERENCES audit_events (id),
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    request_id VARCHAR(128) NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_room_id_idx ON audit_events (room_id, id);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor, id);
+
+-- Journal en ajout seul : les modifications et suppressions sont refusées
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS rooms_team_idx;
+DROP INDEX IF EXISTS rooms_owner_email_idx;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS team;
@@ -0,0 +1,4 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS team VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS rooms_owner_email_idx ON rooms (LOWER(owner_email));
+CREATE INDEX IF NOT EXISTS rooms_team_idx ON rooms (team);
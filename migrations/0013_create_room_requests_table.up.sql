@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS room_requests (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    access_policy VARCHAR(16) NOT NULL DEFAULT 'public',
+    team VARCHAR(255),
+    requester_email VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    reason TEXT NOT NULL DEFAULT '',
+    decided_by VARCHAR(255),
+    decided_at TIMESTAMP,
+    room_id INTEGER REFERENCES rooms (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS room_requests_status_idx ON room_requests (status, created_at);
+CREATE INDEX IF NOT EXISTS room_requests_requester_email_idx ON room_requests (LOWER(requester_email));
+
+-- Un même nom ne peut être demandé qu'une fois à la fois
+CREATE UNIQUE INDEX IF NOT EXISTS room_requests_pending_slug_idx ON room_requests (slug) WHERE status = 'pending';
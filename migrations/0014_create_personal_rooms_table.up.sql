@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS personal_rooms (
+    id SERIAL PRIMARY KEY,
+    owner_email VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    space_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP
+);
+
+-- Une seule salle personnelle par utilisateur, joignable à /u/<slug>
+CREATE UNIQUE INDEX IF NOT EXISTS personal_rooms_owner_email_idx ON personal_rooms (LOWER(owner_email));
+CREATE UNIQUE INDEX IF NOT EXISTS personal_rooms_slug_idx ON personal_rooms (slug);
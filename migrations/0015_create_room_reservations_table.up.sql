@@ -0,0 +1,8 @@
+-- Nom et quota retenus par une création de room pendant l'appel à Google Meet, fait hors transaction
+CREATE TABLE IF NOT EXISTS room_reservations (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(255) NOT NULL UNIQUE,
+    owner_email VARCHAR(255),
+    team VARCHAR(255),
+    expires_at TIMESTAMP NOT NULL
+);